@@ -0,0 +1,184 @@
+package ack
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/curlymon/pipes"
+)
+
+// tracker records which items were acked and nacked.
+type tracker struct {
+	mu    sync.Mutex
+	acked []int
+	nacks []int
+	errs  []error
+}
+
+func (tr *tracker) message(i int) Message[int] {
+	return New(i, func() {
+		tr.mu.Lock()
+		defer tr.mu.Unlock()
+		tr.acked = append(tr.acked, i)
+	}, func(err error) {
+		tr.mu.Lock()
+		defer tr.mu.Unlock()
+		tr.nacks = append(tr.nacks, i)
+		tr.errs = append(tr.errs, err)
+	})
+}
+
+func (tr *tracker) messages(is ...int) pipes.ChanPull[Message[int]] {
+	ms := make([]Message[int], len(is))
+	for i, v := range is {
+		ms[i] = tr.message(v)
+	}
+
+	return pipes.FromSlice(0, ms)
+}
+
+func (tr *tracker) expect(t *testing.T, acked, nacked []int) {
+	t.Helper()
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	if !slices.Equal(tr.acked, acked) {
+		t.Fatalf("got acked %v, want %v", tr.acked, acked)
+	}
+	if !slices.Equal(tr.nacks, nacked) {
+		t.Fatalf("got nacked %v, want %v", tr.nacks, nacked)
+	}
+}
+
+func TestMessageOnce(t *testing.T) {
+	tr := &tracker{}
+
+	m := tr.message(1)
+	m.Ack()
+	m.Ack()
+	m.Nack(errors.New("late"))
+	// derived Messages share the callbacks
+	With(m, "one").Nack(errors.New("late"))
+
+	n := tr.message(2)
+	With(n, "two").Nack(errors.New("failed"))
+	n.Ack()
+
+	tr.expect(t, []int{1}, []int{2})
+
+	// a Message without callbacks is a no-op
+	New(3, nil, nil).Ack()
+	Message[int]{}.Nack(nil)
+}
+
+func TestFilterAcksDropped(t *testing.T) {
+	tr := &tracker{}
+
+	even := func(i int) bool { return i%2 == 0 }
+	var got []int
+	for m := range Filter(0, even, tr.messages(1, 2, 3, 4)) {
+		got = append(got, m.Value)
+	}
+
+	if !slices.Equal(got, []int{2, 4}) {
+		t.Fatalf("got %v, want [2 4]", got)
+	}
+	tr.expect(t, []int{1, 3}, nil)
+}
+
+func TestMapNacksErrors(t *testing.T) {
+	tr := &tracker{}
+
+	errOdd := errors.New("odd")
+	half := func(i int) (int, error) {
+		if i%2 != 0 {
+			return 0, errOdd
+		}
+		return i / 2, nil
+	}
+
+	Sink(func(int) error { return nil }, Map(0, half, tr.messages(1, 2, 3, 4)))
+
+	tr.expect(t, []int{2, 4}, []int{1, 3})
+	for _, err := range tr.errs {
+		if !errors.Is(err, errOdd) {
+			t.Fatalf("got %v, want %v", err, errOdd)
+		}
+	}
+}
+
+func TestSinkNacksErrors(t *testing.T) {
+	tr := &tracker{}
+
+	errThree := errors.New("three")
+	Sink(func(i int) error {
+		if i == 3 {
+			return errThree
+		}
+		return nil
+	}, tr.messages(1, 2, 3))
+
+	tr.expect(t, []int{1, 2}, []int{3})
+}
+
+func TestBatchFansOut(t *testing.T) {
+	tr := &tracker{}
+
+	batches := pipes.ToSlice(Batch(0, 2, time.Hour, tr.messages(1, 2, 3, 4, 5)))
+	if len(batches) != 3 {
+		t.Fatalf("got %d batches, want 3", len(batches))
+	}
+	for i, want := range [][]int{{1, 2}, {3, 4}, {5}} {
+		if !slices.Equal(batches[i].Value, want) {
+			t.Fatalf("batch %d got %v, want %v", i, batches[i].Value, want)
+		}
+	}
+
+	batches[0].Ack()
+	batches[1].Nack(errors.New("failed"))
+	batches[2].Ack()
+
+	tr.expect(t, []int{1, 2, 5}, []int{3, 4})
+}
+
+func TestBatchFlushesOnWindow(t *testing.T) {
+	tr := &tracker{}
+
+	in := make(chan Message[int])
+	out := Batch(0, 10, 10*time.Millisecond, in)
+
+	in <- tr.message(1)
+	in <- tr.message(2)
+
+	select {
+	case b := <-out:
+		if !slices.Equal(b.Value, []int{1, 2}) {
+			t.Fatalf("got %v, want [1 2]", b.Value)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("batch not flushed by the window")
+	}
+
+	close(in)
+	if b, ok := <-out; ok {
+		t.Fatalf("got %v, want no empty batch flushed on close", b.Value)
+	}
+}
+
+func TestWrapUnwrap(t *testing.T) {
+	tr := &tracker{}
+
+	wrapped := Wrap(0, func(i int) (func(), func(error)) {
+		m := tr.message(i)
+		return m.Ack, m.Nack
+	}, pipes.FromSlice(0, []int{1, 2, 3}))
+
+	if got := pipes.ToSlice(Unwrap(0, wrapped)); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("got %v, want [1 2 3]", got)
+	}
+	tr.expect(t, []int{1, 2, 3}, nil)
+}
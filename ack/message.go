@@ -0,0 +1,58 @@
+// Package ack provides at-least-once processing of items through acknowledgement envelopes.
+//
+// A source backed by a queue, broker or file wraps each item in a Message carrying the callbacks
+// that commit or reject it. The stages of this package propagate those callbacks as items are
+// transformed so that a Message is only acknowledged once fully processed: when its result is
+// sunk, when it is filtered out, or when the batch it joined is sunk.
+package ack
+
+import "sync"
+
+// Message is an item along with the callbacks acknowledging or rejecting it. Only the first call to
+// Ack or Nack, on a Message or any Message derived from it with With, has any effect.
+type Message[T any] struct {
+	Value T
+	state *state
+}
+
+type state struct {
+	once sync.Once
+	ack  func()
+	nack func(error)
+}
+
+// New returns a Message carrying t. Either callback may be nil.
+func New[T any](t T, ack func(), nack func(error)) Message[T] {
+	return Message[T]{Value: t, state: &state{ack: ack, nack: nack}}
+}
+
+// With returns a Message carrying n that shares the callbacks of m.
+func With[T any, N any](m Message[T], n N) Message[N] {
+	return Message[N]{Value: n, state: m.state}
+}
+
+// Ack reports the Message as fully processed.
+func (m Message[T]) Ack() {
+	if m.state == nil {
+		return
+	}
+
+	m.state.once.Do(func() {
+		if m.state.ack != nil {
+			m.state.ack()
+		}
+	})
+}
+
+// Nack reports the Message as failed with err, the source may redeliver it.
+func (m Message[T]) Nack(err error) {
+	if m.state == nil {
+		return
+	}
+
+	m.state.once.Do(func() {
+		if m.state.nack != nil {
+			m.state.nack(err)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package ack
+
+import (
+	"time"
+
+	"github.com/curlymon/pipes"
+)
+
+// Map transforms the value of each Message read from in with mp, carrying its callbacks to the
+// result. A Message for which mp fails is nacked with the error and dropped.
+func Map[T any, N any](size int, mp func(T) (N, error), in <-chan Message[T]) pipes.ChanPull[Message[N]] {
+	out := make(chan Message[N], size)
+
+	go mapWorker(mp, in, out)
+
+	return out
+}
+
+func mapWorker[T any, N any](mp func(T) (N, error), in <-chan Message[T], out chan<- Message[N]) {
+	defer close(out)
+
+	for m := range in {
+		if n, err := mp(m.Value); err != nil {
+			m.Nack(err)
+		} else {
+			out <- With(m, n)
+		}
+	}
+}
+
+// Filter passes on each Message read from in whose value filter keeps. A Message filtered out has
+// been fully processed and is acked.
+func Filter[T any](size int, filter func(T) bool, in <-chan Message[T]) pipes.ChanPull[Message[T]] {
+	out := make(chan Message[T], size)
+
+	go filterWorker(filter, in, out)
+
+	return out
+}
+
+func filterWorker[T any](filter func(T) bool, in <-chan Message[T], out chan<- Message[T]) {
+	defer close(out)
+
+	for m := range in {
+		if filter(m.Value) {
+			out <- m
+		} else {
+			m.Ack()
+		}
+	}
+}
+
+// Batch groups the values of Messages read from in into batches of up to count values, emitting a
+// batch once full or once window has passed since its first value was read. Acking or nacking a
+// batch acks or nacks every Message in it.
+func Batch[T any](size, count int, window time.Duration, in <-chan Message[T]) pipes.ChanPull[Message[[]T]] {
+	out := make(chan Message[[]T], size)
+
+	go batchWorker(count, window, in, out)
+
+	return out
+}
+
+func batchWorker[T any](count int, window time.Duration, in <-chan Message[T], out chan<- Message[[]T]) {
+	defer close(out)
+
+	if count < 1 {
+		count = 1
+	}
+
+	timer := time.NewTimer(window)
+	timer.Stop()
+
+	var batch []Message[T]
+	emit := func() {
+		timer.Stop()
+		if len(batch) == 0 {
+			return
+		}
+
+		msgs := batch
+		values := make([]T, len(msgs))
+		for i, m := range msgs {
+			values[i] = m.Value
+		}
+
+		out <- New(values, func() {
+			for _, m := range msgs {
+				m.Ack()
+			}
+		}, func(err error) {
+			for _, m := range msgs {
+				m.Nack(err)
+			}
+		})
+		batch = nil
+	}
+
+	for {
+		select {
+		case m, ok := <-in:
+			if !ok {
+				emit()
+				return
+			}
+
+			if batch = append(batch, m); len(batch) == 1 {
+				timer.Reset(window)
+			}
+			if len(batch) >= count {
+				emit()
+			}
+
+		case <-timer.C:
+			emit()
+		}
+	}
+}
+
+// Sink is a blocking operation that passes the value of each Message read from in to sink, acking
+// the Message if sink succeeds and nacking it with the error otherwise.
+func Sink[T any](sink func(T) error, in <-chan Message[T]) {
+	for m := range in {
+		if err := sink(m.Value); err != nil {
+			m.Nack(err)
+		} else {
+			m.Ack()
+		}
+	}
+}
+
+// Wrap wraps each T read from in into a Message with the callbacks returned by callbacks for it.
+func Wrap[T any](size int, callbacks func(T) (ack func(), nack func(error)), in <-chan T) pipes.ChanPull[Message[T]] {
+	return pipes.Map(size, func(t T) Message[T] {
+		a, n := callbacks(t)
+		return New(t, a, n)
+	}, in)
+}
+
+// Unwrap pushes the value of each Message read from in, acking the Message once its value has
+// been accepted. This marks the end of at-least-once processing.
+func Unwrap[T any](size int, in <-chan Message[T]) pipes.ChanPull[T] {
+	out := make(chan T, size)
+
+	go unwrapWorker(in, out)
+
+	return out
+}
+
+func unwrapWorker[T any](in <-chan Message[T], out chan<- T) {
+	defer close(out)
+
+	for m := range in {
+		out <- m.Value
+		m.Ack()
+	}
+}
@@ -0,0 +1,68 @@
+// Package agg provides reusable streaming aggregations that plug directly into pipes.Reduce,
+// pipes.ReduceAndEmit, pipes.Scan and pipes.Window.
+//
+// Every aggregation is a pointer type implementing Aggregator and is used through the generic Add
+// reduce function:
+//
+//	sizes := pipes.Reduce(agg.Add, agg.NewVariance[int64](), in)
+//	perSecond := pipes.Window(size, time.Second, agg.Add, agg.Histograms[int64](bounds...), in)
+//
+// As the accumulator is a pointer, stages emitting the accumulator more than once, such as
+// ReduceAndEmit and Scan, emit the same aggregation each time.
+package agg
+
+// Aggregator accumulates a stream of T.
+type Aggregator[T any] interface {
+	Add(T)
+}
+
+// Add adds t to a returning a, making any Aggregator usable as the reduce function of pipes.Reduce,
+// pipes.ReduceAndEmit, pipes.Scan and pipes.Window.
+func Add[T any, A Aggregator[T]](t T, a A) A {
+	a.Add(t)
+	return a
+}
+
+// Keyed maintains a separate aggregation per key, turning any windowed aggregation into a keyed
+// window. Aggregations are created by the new function on the first T seen for their key.
+type Keyed[K comparable, T any, A Aggregator[T]] struct {
+	key    func(T) K
+	new    func() A
+	groups map[K]A
+}
+
+// NewKeyed returns an empty Keyed aggregating each T into the aggregation for key(t).
+func NewKeyed[K comparable, T any, A Aggregator[T]](key func(T) K, new func() A) *Keyed[K, T, A] {
+	return &Keyed[K, T, A]{key: key, new: new, groups: make(map[K]A)}
+}
+
+// ByKey returns a function creating a new Keyed, for use as the acc function of pipes.Window.
+func ByKey[K comparable, T any, A Aggregator[T]](key func(T) K, new func() A) func() *Keyed[K, T, A] {
+	return func() *Keyed[K, T, A] { return NewKeyed(key, new) }
+}
+
+func (k *Keyed[K, T, A]) Add(t T) {
+	kk := k.key(t)
+	a, ok := k.groups[kk]
+	if !ok {
+		a = k.new()
+		k.groups[kk] = a
+	}
+
+	a.Add(t)
+}
+
+// Get returns the aggregation for key, false if no T has been seen for it.
+func (k *Keyed[K, T, A]) Get(key K) (A, bool) {
+	a, ok := k.groups[key]
+	return a, ok
+}
+
+// Groups returns the aggregations by key. The map is owned by k and must not be modified.
+func (k *Keyed[K, T, A]) Groups() map[K]A {
+	return k.groups
+}
+
+func (k *Keyed[K, T, A]) Len() int {
+	return len(k.groups)
+}
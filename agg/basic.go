@@ -0,0 +1,141 @@
+package agg
+
+import (
+	"cmp"
+
+	"github.com/curlymon/pipes"
+)
+
+// Count counts the items seen. The zero value is ready to use.
+type Count[T any] struct {
+	n int
+}
+
+func NewCount[T any]() *Count[T] {
+	return &Count[T]{}
+}
+
+func (c *Count[T]) Add(T) {
+	c.n++
+}
+
+// Merge adds the items counted by o to c.
+func (c *Count[T]) Merge(o *Count[T]) {
+	c.n += o.n
+}
+
+func (c *Count[T]) Value() int {
+	return c.n
+}
+
+// Sum totals the items seen. The zero value is ready to use.
+type Sum[T pipes.Number] struct {
+	total T
+}
+
+func NewSum[T pipes.Number]() *Sum[T] {
+	return &Sum[T]{}
+}
+
+func (s *Sum[T]) Add(t T) {
+	s.total += t
+}
+
+// Merge adds the total of o to s.
+func (s *Sum[T]) Merge(o *Sum[T]) {
+	s.total += o.total
+}
+
+func (s *Sum[T]) Value() T {
+	return s.total
+}
+
+// Min tracks the smallest item seen. The zero value is ready to use.
+type Min[T cmp.Ordered] struct {
+	min T
+	ok  bool
+}
+
+func NewMin[T cmp.Ordered]() *Min[T] {
+	return &Min[T]{}
+}
+
+func (m *Min[T]) Add(t T) {
+	if !m.ok || t < m.min {
+		m.min, m.ok = t, true
+	}
+}
+
+// Merge folds the minimum seen by o into m.
+func (m *Min[T]) Merge(o *Min[T]) {
+	if o.ok {
+		m.Add(o.min)
+	}
+}
+
+// Value returns the smallest item seen, false if none have been.
+func (m *Min[T]) Value() (T, bool) {
+	return m.min, m.ok
+}
+
+// Max tracks the largest item seen. The zero value is ready to use.
+type Max[T cmp.Ordered] struct {
+	max T
+	ok  bool
+}
+
+func NewMax[T cmp.Ordered]() *Max[T] {
+	return &Max[T]{}
+}
+
+func (m *Max[T]) Add(t T) {
+	if !m.ok || t > m.max {
+		m.max, m.ok = t, true
+	}
+}
+
+// Merge folds the maximum seen by o into m.
+func (m *Max[T]) Merge(o *Max[T]) {
+	if o.ok {
+		m.Add(o.max)
+	}
+}
+
+// Value returns the largest item seen, false if none have been.
+func (m *Max[T]) Value() (T, bool) {
+	return m.max, m.ok
+}
+
+// Mean tracks the arithmetic mean of the items seen. The zero value is ready to use.
+type Mean[T pipes.Number] struct {
+	n   int
+	sum float64
+}
+
+func NewMean[T pipes.Number]() *Mean[T] {
+	return &Mean[T]{}
+}
+
+func (m *Mean[T]) Add(t T) {
+	m.n++
+	m.sum += float64(t)
+}
+
+// Merge folds the items seen by o into m.
+func (m *Mean[T]) Merge(o *Mean[T]) {
+	m.n += o.n
+	m.sum += o.sum
+}
+
+func (m *Mean[T]) Count() int {
+	return m.n
+}
+
+// Value returns the mean of the items seen, zero if none have been.
+func (m *Mean[T]) Value() float64 {
+	if m.n == 0 {
+		return 0
+	}
+
+	return m.sum / float64(m.n)
+}
@@ -0,0 +1,24 @@
+package agg_test
+
+import (
+	"fmt"
+
+	"github.com/curlymon/pipes"
+	"github.com/curlymon/pipes/agg"
+)
+
+func Example() {
+	sizes := []int64{1, 5, 20, 50, 200, 500}
+
+	variance := pipes.Reduce(agg.Add, agg.NewVariance[int64](), pipes.FromSlice(0, sizes))
+	fmt.Printf("mean %.1f\n", variance.Mean())
+
+	histogram := pipes.ReduceAndEmit(agg.Add, agg.NewHistogram[int64](10, 100), pipes.FromSlice(0, sizes))
+	for h := range histogram {
+		fmt.Println(h.Bounds(), h.Counts())
+	}
+
+	// Output:
+	// mean 129.3
+	// [10 100] [2 2 2]
+}
@@ -0,0 +1,75 @@
+package agg
+
+import (
+	"errors"
+	"slices"
+	"sort"
+
+	"github.com/curlymon/pipes"
+)
+
+// ErrBounds is returned when merging histograms with differing bucket bounds.
+var ErrBounds = errors.New("agg: histogram bounds differ")
+
+// Histogram counts the items seen into buckets. Bucket i counts the items greater than bound i-1
+// and less than or equal to bound i, with a final overflow bucket counting items above the last
+// bound.
+type Histogram[T pipes.Number] struct {
+	bounds []T
+	counts []int
+}
+
+// NewHistogram returns an empty Histogram with the given upper bucket bounds, which are sorted and
+// deduplicated.
+func NewHistogram[T pipes.Number](bounds ...T) *Histogram[T] {
+	bounds = slices.Clone(bounds)
+	slices.Sort(bounds)
+	bounds = slices.Compact(bounds)
+
+	return &Histogram[T]{bounds: bounds, counts: make([]int, len(bounds)+1)}
+}
+
+// Histograms returns a function creating a new Histogram with bounds, for use as the acc function
+// of pipes.Window.
+func Histograms[T pipes.Number](bounds ...T) func() *Histogram[T] {
+	bounds = slices.Clone(bounds)
+	return func() *Histogram[T] { return NewHistogram(bounds...) }
+}
+
+func (h *Histogram[T]) Add(t T) {
+	h.counts[sort.Search(len(h.bounds), func(i int) bool { return t <= h.bounds[i] })]++
+}
+
+// Merge adds the counts of o to h, both must have been created with the same bounds.
+func (h *Histogram[T]) Merge(o *Histogram[T]) error {
+	if !slices.Equal(h.bounds, o.bounds) {
+		return ErrBounds
+	}
+
+	for i, n := range o.counts {
+		h.counts[i] += n
+	}
+
+	return nil
+}
+
+// Bounds returns the upper bucket bounds. The slice is owned by h and must not be modified.
+func (h *Histogram[T]) Bounds() []T {
+	return h.bounds
+}
+
+// Counts returns the count of each bucket, one longer than Bounds with the overflow bucket last.
+// The slice is owned by h and must not be modified.
+func (h *Histogram[T]) Counts() []int {
+	return h.counts
+}
+
+// Count returns the total items seen.
+func (h *Histogram[T]) Count() int {
+	n := 0
+	for _, c := range h.counts {
+		n += c
+	}
+
+	return n
+}
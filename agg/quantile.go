@@ -0,0 +1,123 @@
+package agg
+
+import (
+	"math"
+	"slices"
+
+	"github.com/curlymon/pipes"
+)
+
+// Quantile estimates a single quantile of the items seen in constant memory using the P² algorithm
+// of Jain and Chlamtac, which tracks five markers whose heights are adjusted as items arrive. The
+// estimate is exact for up to five items.
+//
+// Unlike the other aggregations P² estimates cannot be merged, use a Histogram where per-worker
+// distributions need to be combined.
+type Quantile[T pipes.Number] struct {
+	p       float64
+	n       int
+	heights [5]float64 // marker heights, the quantile estimate is heights[2]
+	pos     [5]float64 // actual marker positions
+	want    [5]float64 // desired marker positions
+	incr    [5]float64 // desired position increments per item
+}
+
+// NewQuantile returns an empty Quantile estimating the p quantile, where p is within [0, 1].
+func NewQuantile[T pipes.Number](p float64) *Quantile[T] {
+	p = math.Max(0, math.Min(1, p))
+
+	return &Quantile[T]{
+		p:    p,
+		pos:  [5]float64{0, 1, 2, 3, 4},
+		want: [5]float64{0, 2 * p, 4 * p, 2 + 2*p, 4},
+		incr: [5]float64{0, p / 2, p, (1 + p) / 2, 1},
+	}
+}
+
+// Quantiles returns a function creating a new Quantile for p, for use as the acc function of
+// pipes.Window.
+func Quantiles[T pipes.Number](p float64) func() *Quantile[T] {
+	return func() *Quantile[T] { return NewQuantile[T](p) }
+}
+
+func (q *Quantile[T]) Add(t T) {
+	x := float64(t)
+
+	// the first five items seed the markers
+	if q.n < 5 {
+		q.heights[q.n] = x
+		q.n++
+		if q.n == 5 {
+			slices.Sort(q.heights[:])
+		}
+		return
+	}
+	q.n++
+
+	// find the cell x falls in, extending the extremes as needed
+	var k int
+	switch {
+	case x < q.heights[0]:
+		q.heights[0] = x
+		k = 0
+	case x >= q.heights[4]:
+		q.heights[4] = x
+		k = 3
+	default:
+		for k = 0; k < 3 && x >= q.heights[k+1]; k++ {
+		}
+	}
+
+	for i := k + 1; i < 5; i++ {
+		q.pos[i]++
+	}
+	for i := range q.want {
+		q.want[i] += q.incr[i]
+	}
+
+	// move the middle markers towards their desired positions
+	for i := 1; i < 4; i++ {
+		d := q.want[i] - q.pos[i]
+		if (d >= 1 && q.pos[i+1]-q.pos[i] > 1) || (d <= -1 && q.pos[i-1]-q.pos[i] < -1) {
+			d = math.Copysign(1, d)
+			if h := q.parabolic(i, d); q.heights[i-1] < h && h < q.heights[i+1] {
+				q.heights[i] = h
+			} else {
+				q.heights[i] = q.linear(i, d)
+			}
+			q.pos[i] += d
+		}
+	}
+}
+
+// parabolic returns the piecewise-parabolic prediction of the height of marker i moved by d.
+func (q *Quantile[T]) parabolic(i int, d float64) float64 {
+	h, n := q.heights, q.pos
+	return h[i] + d/(n[i+1]-n[i-1])*((n[i]-n[i-1]+d)*(h[i+1]-h[i])/(n[i+1]-n[i])+(n[i+1]-n[i]-d)*(h[i]-h[i-1])/(n[i]-n[i-1]))
+}
+
+// linear returns the linear prediction of the height of marker i moved by d.
+func (q *Quantile[T]) linear(i int, d float64) float64 {
+	j := i + int(d)
+	return q.heights[i] + d*(q.heights[j]-q.heights[i])/(q.pos[j]-q.pos[i])
+}
+
+func (q *Quantile[T]) Count() int {
+	return q.n
+}
+
+// Value returns the estimated quantile, zero if no items have been seen.
+func (q *Quantile[T]) Value() float64 {
+	if q.n == 0 {
+		return 0
+	}
+
+	// exact until the markers start moving after the fifth item
+	if q.n <= 5 {
+		seen := slices.Clone(q.heights[:q.n])
+		slices.Sort(seen)
+		return seen[int(math.Round(q.p*float64(q.n-1)))]
+	}
+
+	return q.heights[2]
+}
@@ -0,0 +1,32 @@
+package agg
+
+import "testing"
+
+func TestQuantileExactForFewItems(t *testing.T) {
+	for n := 1; n <= 5; n++ {
+		for _, p := range []float64{0, 0.5, 1} {
+			q := NewQuantile[int](p)
+			// added in reverse so that the exact path must sort
+			for i := n; i > 0; i-- {
+				q.Add(i)
+			}
+
+			// the median of an even count rounds up to the upper middle item
+			want := map[float64]float64{0: 1, 0.5: float64(n/2 + 1), 1: float64(n)}[p]
+			if got := q.Value(); got != want {
+				t.Fatalf("n %d p %v got %v, want %v", n, p, got, want)
+			}
+		}
+	}
+}
+
+func TestQuantileEstimate(t *testing.T) {
+	q := NewQuantile[int](0.9)
+	for i := range 10000 {
+		q.Add(i * 7919 % 10000)
+	}
+
+	if got := q.Value(); got < 8900 || got > 9100 {
+		t.Fatalf("got %v, want about 9000", got)
+	}
+}
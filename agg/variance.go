@@ -0,0 +1,81 @@
+package agg
+
+import (
+	"math"
+
+	"github.com/curlymon/pipes"
+)
+
+// Variance tracks the count, mean, variance and standard deviation of the items seen using
+// Welford's online algorithm, which stays numerically stable over long streams. The zero value is
+// ready to use.
+type Variance[T pipes.Number] struct {
+	n    int
+	mean float64
+	m2   float64 // sum of squared differences from the mean
+}
+
+func NewVariance[T pipes.Number]() *Variance[T] {
+	return &Variance[T]{}
+}
+
+func (v *Variance[T]) Add(t T) {
+	x := float64(t)
+	v.n++
+	delta := x - v.mean
+	v.mean += delta / float64(v.n)
+	v.m2 += delta * (x - v.mean)
+}
+
+// Merge folds the items seen by o into v as if v had seen them itself.
+func (v *Variance[T]) Merge(o *Variance[T]) {
+	if o.n == 0 {
+		return
+	}
+	if v.n == 0 {
+		*v = *o
+		return
+	}
+
+	n := v.n + o.n
+	delta := o.mean - v.mean
+	v.m2 += o.m2 + delta*delta*float64(v.n)*float64(o.n)/float64(n)
+	v.mean += delta * float64(o.n) / float64(n)
+	v.n = n
+}
+
+func (v *Variance[T]) Count() int {
+	return v.n
+}
+
+func (v *Variance[T]) Mean() float64 {
+	return v.mean
+}
+
+// Variance returns the population variance of the items seen, zero if none have been.
+func (v *Variance[T]) Variance() float64 {
+	if v.n == 0 {
+		return 0
+	}
+
+	return v.m2 / float64(v.n)
+}
+
+// SampleVariance returns the unbiased sample variance of the items seen, zero for fewer than two.
+func (v *Variance[T]) SampleVariance() float64 {
+	if v.n < 2 {
+		return 0
+	}
+
+	return v.m2 / float64(v.n-1)
+}
+
+// StdDev returns the population standard deviation of the items seen.
+func (v *Variance[T]) StdDev() float64 {
+	return math.Sqrt(v.Variance())
+}
+
+// SampleStdDev returns the sample standard deviation of the items seen.
+func (v *Variance[T]) SampleStdDev() float64 {
+	return math.Sqrt(v.SampleVariance())
+}
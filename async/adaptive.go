@@ -0,0 +1,232 @@
+package async
+
+import (
+	"sync"
+	"time"
+
+	"github.com/curlymon/pipes"
+)
+
+// Scaling configures the worker pool of MapAdaptive.
+type Scaling struct {
+	// Min is the number of workers always kept running. Defaults to 1.
+	Min int
+	// Max is the upper bound of workers that may be running. Defaults to Min.
+	Max int
+	// Interval is how often a scaling decision is made. Defaults to 100ms.
+	Interval time.Duration
+	// Cooldown is how long a worker above Min may sit idle before exiting. Defaults to 1s.
+	Cooldown time.Duration
+	// Clock is the source of time for Interval, Cooldown and latencies, nil uses pipes.SystemClock.
+	Clock pipes.Clock
+}
+
+// MapAdaptive behaves as Map with the number of workers scaled between scaling.Min and scaling.Max.
+// Every scaling.Interval another worker is added if all current workers are busy, items are queued
+// on in, and the output is not applying backpressure. Where in is buffered a worker is only added
+// if, going by the observed per-item latency, the queue would take longer than an interval to clear
+// at the current worker count. Workers above scaling.Min exit once idle for scaling.Cooldown.
+func MapAdaptive[T any, N any](scaling Scaling, size int, mp func(T) N, in <-chan T) pipes.ChanPull[N] {
+	out := make(chan N, size)
+
+	go mapAdaptiveCoordinator(scaling, func(t T) (N, error) { return mp(t), nil }, nil, in, out)
+
+	return out
+}
+
+// MapAdaptiveWithError behaves as MapAdaptive pushing any error returned by mp onto the returned
+// error channel in place of a result. Returning pipes.ErrStop shuts down every worker.
+func MapAdaptiveWithError[T any, N any](scaling Scaling, size int, mp func(T) (N, error), in <-chan T) (pipes.ChanPull[N], pipes.ChanPull[error]) {
+	out, err := make(chan N, size), make(chan error, size)
+
+	go mapAdaptiveWithErrorCoordinator(scaling, mp, in, out, err)
+
+	return out, err
+}
+
+func mapAdaptiveWithErrorCoordinator[T any, N any](scaling Scaling, mp func(T) (N, error), in <-chan T, out chan<- N, err chan<- error) {
+	defer close(err)
+
+	mapAdaptiveCoordinator(scaling, mp, func(er error) { err <- er }, in, out)
+}
+
+// MapAdaptiveWithErrorSink behaves as MapAdaptiveWithError passing errors to sink.
+func MapAdaptiveWithErrorSink[T any, N any](scaling Scaling, size int, mp func(T) (N, error), sink func(error), in <-chan T) pipes.ChanPull[N] {
+	out := make(chan N, size)
+
+	go mapAdaptiveCoordinator(scaling, mp, sink, in, out)
+
+	return out
+}
+
+// adaptive holds the state shared between the MapAdaptive coordinator and its workers.
+type adaptive struct {
+	mu      sync.Mutex
+	workers int
+	busy    int
+	closed  bool          // in has been closed, no more workers may be started
+	latency time.Duration // total processing time since the last scaling decision
+	items   int           // items processed since the last scaling decision
+	blocked time.Duration // total time blocked pushing to out since the last scaling decision
+	exited  chan struct{} // closed once the last worker exits
+}
+
+func mapAdaptiveCoordinator[T any, N any](scaling Scaling, mp func(T) (N, error), sink func(error), in <-chan T, out chan<- N) {
+	defer close(out)
+
+	if scaling.Min < 1 {
+		scaling.Min = 1
+	}
+	if scaling.Max < scaling.Min {
+		scaling.Max = scaling.Min
+	}
+	if scaling.Interval <= 0 {
+		scaling.Interval = 100 * time.Millisecond
+	}
+	if scaling.Cooldown <= 0 {
+		scaling.Cooldown = time.Second
+	}
+	if scaling.Clock == nil {
+		scaling.Clock = pipes.SystemClock
+	}
+
+	s := newStop()
+	a := &adaptive{workers: scaling.Min, exited: make(chan struct{})}
+	for i := 0; i < scaling.Min; i++ {
+		go mapAdaptiveWorker(a, s, scaling, mp, sink, in, out)
+	}
+
+	ticker := scaling.Clock.NewTicker(scaling.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.exited:
+			release(s, in)
+			return
+
+		case <-ticker.C():
+			if a.grow(scaling, len(in), cap(in), len(out), cap(out)) {
+				go mapAdaptiveWorker(a, s, scaling, mp, sink, in, out)
+			}
+		}
+	}
+}
+
+// grow decides whether another worker should be started, registering it as running if so.
+func (a *adaptive) grow(scaling Scaling, inLen, inCap, outLen, outCap int) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	latency, items, blocked := a.latency, a.items, a.blocked
+	a.latency, a.items, a.blocked = 0, 0, 0
+
+	if a.closed || a.workers >= scaling.Max || a.busy < a.workers {
+		return false
+	}
+
+	// downstream is not keeping up, more workers would only block on out as well
+	if (outCap > 0 && outLen >= outCap) || blocked > latency {
+		return false
+	}
+
+	if inCap > 0 {
+		if inLen == 0 {
+			return false
+		}
+
+		if items > 0 {
+			avg := latency / time.Duration(items)
+			if avg*time.Duration(inLen)/time.Duration(a.workers) < scaling.Interval {
+				return false
+			}
+		}
+	}
+
+	a.workers++
+
+	return true
+}
+
+// idle decides whether an idle worker should exit, deregistering it if so.
+func (a *adaptive) idle(scaling Scaling) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.workers <= scaling.Min {
+		return false
+	}
+
+	a.workers--
+
+	return true
+}
+
+// done deregisters a worker after in has been closed or the stage stopped.
+func (a *adaptive) done() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.closed = true
+	if a.workers--; a.workers == 0 {
+		close(a.exited)
+	}
+}
+
+func mapAdaptiveWorker[T any, N any](a *adaptive, s *stop, scaling Scaling, mp func(T) (N, error), sink func(error), in <-chan T, out chan<- N) {
+	idle := scaling.Clock.NewTimer(scaling.Cooldown)
+	defer idle.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			a.done()
+			return
+
+		case <-idle.C():
+			if a.idle(scaling) {
+				return
+			}
+			idle.Reset(scaling.Cooldown)
+
+		case t, ok := <-in:
+			if !ok {
+				a.done()
+				return
+			}
+
+			// the idle timer only runs while waiting on in
+			idle.Stop()
+
+			a.mu.Lock()
+			a.busy++
+			a.mu.Unlock()
+
+			start := scaling.Clock.Now()
+			n, err := mp(t)
+			latency := scaling.Clock.Now().Sub(start)
+
+			var blocked time.Duration
+			if err != nil {
+				sink(err)
+			} else {
+				start = scaling.Clock.Now()
+				out <- n
+				blocked = scaling.Clock.Now().Sub(start)
+			}
+
+			a.mu.Lock()
+			a.busy--
+			a.latency += latency
+			a.blocked += blocked
+			a.items++
+			a.mu.Unlock()
+
+			if s.stopping(err) {
+				a.done()
+				return
+			}
+			idle.Reset(scaling.Cooldown)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package async
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/curlymon/pipes"
+)
+
+// waitWaiters waits for the timers and tickers pending on clock to reach n. A MapAdaptive stage has
+// its ticker pending along with the idle timer of every worker waiting on in.
+func waitWaiters(t *testing.T, clock *pipes.ManualClock, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for clock.Waiters() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d waiters, want %d", clock.Waiters(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// adaptiveStage starts a MapAdaptive stage over an unbuffered in whose mp reports each item on
+// started and then blocks until release is closed.
+func adaptiveStage(scaling Scaling) (in chan int, started chan int, release chan struct{}, out pipes.ChanPull[int]) {
+	in, started, release = make(chan int), make(chan int, 100), make(chan struct{})
+	out = MapAdaptive(scaling, 100, func(i int) int {
+		started <- i
+		<-release
+		return i
+	}, in)
+
+	return in, started, release, out
+}
+
+func TestMapAdaptiveScalesUpToMax(t *testing.T) {
+	clock := pipes.NewManualClock(time.Unix(0, 0))
+	scaling := Scaling{Min: 1, Max: 3, Interval: time.Second, Cooldown: time.Minute, Clock: clock}
+	in, started, release, out := adaptiveStage(scaling)
+
+	go func() {
+		defer close(in)
+		for i := range 10 {
+			in <- i
+		}
+	}()
+
+	<-started
+	waitWaiters(t, clock, 1)
+
+	// every worker is busy with items still queued, a worker is added each interval
+	for range 2 {
+		clock.Advance(scaling.Interval)
+		<-started
+		waitWaiters(t, clock, 1)
+	}
+
+	clock.Advance(scaling.Interval)
+	select {
+	case i := <-started:
+		t.Fatalf("item %d started beyond Max workers", i)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	if n := out.Count(); n != 10 {
+		t.Fatalf("got %d items, want 10", n)
+	}
+}
+
+func TestMapAdaptiveScalesDownAfterCooldown(t *testing.T) {
+	clock := pipes.NewManualClock(time.Unix(0, 0))
+	scaling := Scaling{Min: 1, Max: 3, Interval: time.Second, Cooldown: time.Minute, Clock: clock}
+	in, started, release, out := adaptiveStage(scaling)
+
+	go func() {
+		for i := range 3 {
+			in <- i
+		}
+	}()
+
+	<-started
+	waitWaiters(t, clock, 1)
+	for range 2 {
+		clock.Advance(scaling.Interval)
+		<-started
+		waitWaiters(t, clock, 1)
+	}
+
+	close(release)
+	for range 3 {
+		<-out
+	}
+	// the ticker and all three workers idle
+	waitWaiters(t, clock, 4)
+
+	clock.Advance(scaling.Cooldown - time.Nanosecond)
+	if n := clock.Waiters(); n != 4 {
+		t.Fatalf("got %d waiters before the cooldown, want 4", n)
+	}
+
+	// all three go idle together, only those above Min exit
+	clock.Advance(time.Nanosecond)
+	waitWaiters(t, clock, 2)
+
+	in <- 3
+	close(in)
+	if got := out.ToSlice(); !slices.Equal(got, []int{3}) {
+		t.Fatalf("got %v, want [3]", got)
+	}
+}
+
+func TestMapAdaptiveWithErrorStops(t *testing.T) {
+	clock := pipes.NewManualClock(time.Unix(0, 0))
+	scaling := Scaling{Min: 2, Clock: clock}
+
+	in := make(chan int)
+	sent := make(chan int)
+	go func() {
+		defer close(sent)
+		n := 0
+		for ; n < 100; n++ {
+			in <- n
+		}
+		close(in)
+		sent <- n
+	}()
+
+	fail := errors.New("fail")
+	out, errs := MapAdaptiveWithError(scaling, 100, func(i int) (int, error) {
+		switch i {
+		case 1:
+			return 0, fail
+		case 5:
+			return 0, pipes.ErrStop
+		}
+		return i, nil
+	}, in)
+
+	count := make(chan int)
+	go func() { count <- out.Count() }()
+
+	var got []error
+	for err := range errs {
+		got = append(got, err)
+	}
+	if !slices.Contains(got, fail) || !slices.Contains(got, pipes.ErrStop) {
+		t.Fatalf("got %v, want both fail and ErrStop", got)
+	}
+	if n := <-count; n >= 98 {
+		t.Fatalf("got %d items, want the stage to stop early", n)
+	}
+
+	// the remainder of in is drained after the stop
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("in was not drained")
+	}
+}
+
+func TestMapAdaptiveWithErrorSink(t *testing.T) {
+	var mu sync.Mutex
+	var errs []error
+	out := MapAdaptiveWithErrorSink(Scaling{Min: 2, Max: 4}, 0, func(i int) (int, error) {
+		if i%2 == 1 {
+			return 0, errors.New("odd")
+		}
+		return i, nil
+	}, func(err error) { mu.Lock(); errs = append(errs, err); mu.Unlock() }, pipes.FromSlice(0, []int{0, 1, 2, 3, 4}))
+
+	got := out.ToSlice()
+	slices.Sort(got)
+	if !slices.Equal(got, []int{0, 2, 4}) {
+		t.Fatalf("got %v, want [0 2 4]", got)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("got %v, want 2 errors", errs)
+	}
+}
@@ -0,0 +1,161 @@
+package async
+
+import (
+	"sync"
+
+	"github.com/curlymon/pipes"
+)
+
+// Expand recursively expands each T read from in across count workers. Every T, whether read from
+// in or produced by expand, is pushed onto the returned channel and then passed to expand, with the
+// items it returns fed back in to be expanded in turn. The returned channel is closed once in is
+// closed and no work remains, that is every item has been expanded and none are waiting to be.
+//
+// Items fed back are held in an unbounded internal queue so that workers never block on
+// themselves, which also means no ordering is guaranteed. Items are only read from in while fewer
+// than count are queued, so that a slow consumer pushes back on in.
+func Expand[T any](count, size int, expand func(T) []T, in <-chan T) pipes.ChanPull[T] {
+	return ExpandWithErrorSink(count, size, func(t T) ([]T, error) { return expand(t), nil }, nil, in)
+}
+
+// ExpandWithErrorSink behaves as Expand passing any error returned by expand to sink. The item
+// itself has already been pushed, any items returned alongside the error are still expanded.
+func ExpandWithErrorSink[T any](count, size int, expand func(T) ([]T, error), sink func(error), in <-chan T) pipes.ChanPull[T] {
+	out := make(chan T, size)
+
+	go expandCoordinator(count, expand, sink, in, out)
+
+	return out
+}
+
+// expansion is the work queue shared by the Expand workers.
+type expansion[T any] struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []T
+	pending int  // items queued or being expanded
+	seeding bool // in has not yet been closed
+}
+
+// push queues ts as pending work.
+func (e *expansion[T]) push(ts ...T) {
+	if len(ts) == 0 {
+		return
+	}
+
+	e.mu.Lock()
+	e.queue = append(e.queue, ts...)
+	e.pending += len(ts)
+	e.mu.Unlock()
+
+	e.cond.Broadcast()
+}
+
+// pop blocks until an item is available, returning false once all work is complete.
+func (e *expansion[T]) pop() (T, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for len(e.queue) == 0 && (e.seeding || e.pending > 0) {
+		e.cond.Wait()
+	}
+
+	if len(e.queue) == 0 {
+		var zero T
+		return zero, false
+	}
+
+	// taken from the end so that expansion runs depth first keeping the queue short
+	last := len(e.queue) - 1
+	t := e.queue[last]
+	var zero T
+	e.queue[last] = zero
+	e.queue = e.queue[:last]
+
+	// wakes the coordinator waiting for room
+	e.cond.Broadcast()
+
+	return t, true
+}
+
+// room blocks until fewer than limit items are queued.
+func (e *expansion[T]) room(limit int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for len(e.queue) >= limit {
+		e.cond.Wait()
+	}
+}
+
+// done marks an item popped earlier as expanded.
+func (e *expansion[T]) done() {
+	e.mu.Lock()
+	e.pending--
+	quiet := e.pending == 0 && !e.seeding
+	e.mu.Unlock()
+
+	if quiet {
+		e.cond.Broadcast()
+	}
+}
+
+// seeded marks in as closed.
+func (e *expansion[T]) seeded() {
+	e.mu.Lock()
+	e.seeding = false
+	e.mu.Unlock()
+
+	e.cond.Broadcast()
+}
+
+func expandCoordinator[T any](count int, expand func(T) ([]T, error), sink func(error), in <-chan T, out chan<- T) {
+	defer close(out)
+
+	if count < 1 {
+		count = 1
+	}
+
+	e := &expansion[T]{seeding: true}
+	e.cond = sync.NewCond(&e.mu)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(count)
+	for i := 0; i < count; i++ {
+		go expandWorker(wg, e, expand, sink, out)
+	}
+
+	for {
+		e.room(count)
+
+		t, ok := <-in
+		if !ok {
+			break
+		}
+		e.push(t)
+	}
+	e.seeded()
+
+	wg.Wait()
+}
+
+func expandWorker[T any](wg *sync.WaitGroup, e *expansion[T], expand func(T) ([]T, error), sink func(error), out chan<- T) {
+	defer wg.Done()
+
+	for {
+		t, ok := e.pop()
+		if !ok {
+			return
+		}
+
+		out <- t
+
+		ts, err := expand(t)
+		if err != nil && sink != nil {
+			sink(err)
+		}
+
+		e.push(ts...)
+		e.done()
+	}
+}
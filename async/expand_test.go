@@ -0,0 +1,47 @@
+package async
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/curlymon/pipes"
+)
+
+func TestExpand(t *testing.T) {
+	// expands n into n*10+1 .. n*10+3 until 100
+	children := func(n int) []int {
+		if n >= 10 {
+			return nil
+		}
+		return []int{n*10 + 1, n*10 + 2, n*10 + 3}
+	}
+
+	got := pipes.ToSlice(Expand(4, 0, children, pipes.FromSlice(0, []int{1, 2})))
+	slices.Sort(got)
+
+	want := []int{1, 2, 11, 12, 13, 21, 22, 23}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestExpandPushesBackOnIn(t *testing.T) {
+	in := make(chan int, 100)
+	for i := range 100 {
+		in <- i
+	}
+	close(in)
+
+	out := Expand(1, 0, func(int) []int { return nil }, in)
+
+	// the worker blocks pushing its first item, leaving at most count queued
+	time.Sleep(20 * time.Millisecond)
+	if n := len(in); n < 97 {
+		t.Fatalf("read %d items from in while out is blocked, want at most 3", 100-n)
+	}
+
+	if n := pipes.Count(out); n != 100 {
+		t.Fatalf("got %d items, want 100", n)
+	}
+}
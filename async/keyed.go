@@ -0,0 +1,232 @@
+package async
+
+import (
+	"sync"
+
+	"github.com/curlymon/pipes"
+)
+
+// keyed tracks which worker each key with items in flight is assigned to. A key is only bound to a
+// worker while it has items in flight, items for a key with nothing in flight may go to any worker
+// as everything previously seen for that key has already been emitted.
+type keyed[K comparable] struct {
+	mu      sync.Mutex
+	next    int
+	count   int
+	pending map[K]*keyedPending
+}
+
+type keyedPending struct {
+	worker int
+	items  int
+}
+
+func newKeyed[K comparable](count int) *keyed[K] {
+	return &keyed[K]{count: count, pending: make(map[K]*keyedPending)}
+}
+
+// assign returns the worker an item for k must be processed by.
+func (k *keyed[K]) assign(key K) int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	p, ok := k.pending[key]
+	if !ok {
+		p = &keyedPending{worker: k.next}
+		k.pending[key] = p
+		if k.next++; k.next >= k.count {
+			k.next = 0
+		}
+	}
+	p.items++
+
+	return p.worker
+}
+
+// done releases an item for k once it has been emitted.
+func (k *keyed[K]) done(key K) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	p := k.pending[key]
+	if p.items--; p.items == 0 {
+		delete(k.pending, key)
+	}
+}
+
+// MapKeyed behaves as Map while preserving the order of items sharing the same key. Items are
+// partitioned by key across count workers so that items with the same key are processed serially
+// and emitted in the order read from in, while items with differing keys are processed
+// concurrently.
+func MapKeyed[T any, K comparable, N any](count, size int, key func(T) K, mp func(T) N, in <-chan T) pipes.ChanPull[N] {
+	out := make(chan N, size)
+
+	go mapKeyedCoordinator(count, size, key, mp, in, out)
+
+	return out
+}
+
+func mapKeyedCoordinator[T any, K comparable, N any](count, size int, key func(T) K, mp func(T) N, in <-chan T, out chan<- N) {
+	defer close(out)
+
+	if count < 1 {
+		count = 1
+	}
+
+	k := newKeyed[K](count)
+	parts := make([]chan pipes.KV[K, T], count)
+	wg := &sync.WaitGroup{}
+	wg.Add(count)
+	for i := range parts {
+		parts[i] = make(chan pipes.KV[K, T], size)
+		go mapKeyedWorker(wg, k, mp, parts[i], out)
+	}
+
+	for t := range in {
+		kt := pipes.KV[K, T]{Key: key(t), Value: t}
+		parts[k.assign(kt.Key)] <- kt
+	}
+
+	for _, part := range parts {
+		close(part)
+	}
+
+	wg.Wait()
+}
+
+func mapKeyedWorker[T any, K comparable, N any](wg *sync.WaitGroup, k *keyed[K], mp func(T) N, in <-chan pipes.KV[K, T], out chan<- N) {
+	defer wg.Done()
+
+	for kt := range in {
+		out <- mp(kt.Value)
+		k.done(kt.Key)
+	}
+}
+
+func MapKeyedWithError[T any, K comparable, N any](count, size int, key func(T) K, mp func(T) (N, error), in <-chan T) (pipes.ChanPull[N], pipes.ChanPull[error]) {
+	out, err := make(chan N, size), make(chan error, size)
+
+	go mapKeyedWithErrorCoordinator(count, size, key, mp, in, out, err)
+
+	return out, err
+}
+
+func mapKeyedWithErrorCoordinator[T any, K comparable, N any](count, size int, key func(T) K, mp func(T) (N, error), in <-chan T, out chan<- N, err chan<- error) {
+	defer func() { close(out); close(err) }()
+
+	if count < 1 {
+		count = 1
+	}
+
+	s := newStop()
+	k := newKeyed[K](count)
+	parts := make([]chan pipes.KV[K, T], count)
+	wg := &sync.WaitGroup{}
+	wg.Add(count)
+	for i := range parts {
+		parts[i] = make(chan pipes.KV[K, T], size)
+		go mapKeyedWithErrorWorker(wg, s, k, mp, parts[i], out, err)
+	}
+
+	mapKeyedDispatch(s, k, key, in, parts)
+
+	wg.Wait()
+}
+
+func mapKeyedWithErrorWorker[T any, K comparable, N any](wg *sync.WaitGroup, s *stop, k *keyed[K], mp func(T) (N, error), in <-chan pipes.KV[K, T], out chan<- N, err chan<- error) {
+	defer wg.Done()
+
+	for kt := range in {
+		if n, er := mp(kt.Value); er != nil {
+			err <- er
+			if s.stopping(er) {
+				// release anything left queued for this worker so the dispatcher is not blocked
+				go pipes.ChanPull[pipes.KV[K, T]](in).Drain()
+				return
+			}
+		} else {
+			out <- n
+		}
+		k.done(kt.Key)
+	}
+}
+
+func MapKeyedWithErrorSink[T any, K comparable, N any](count, size int, key func(T) K, mp func(T) (N, error), sink func(error), in <-chan T) pipes.ChanPull[N] {
+	out := make(chan N, size)
+
+	go mapKeyedWithErrorSinkCoordinator(count, size, key, mp, sink, in, out)
+
+	return out
+}
+
+func mapKeyedWithErrorSinkCoordinator[T any, K comparable, N any](count, size int, key func(T) K, mp func(T) (N, error), sink func(error), in <-chan T, out chan<- N) {
+	defer close(out)
+
+	if count < 1 {
+		count = 1
+	}
+
+	s := newStop()
+	k := newKeyed[K](count)
+	parts := make([]chan pipes.KV[K, T], count)
+	wg := &sync.WaitGroup{}
+	wg.Add(count)
+	for i := range parts {
+		parts[i] = make(chan pipes.KV[K, T], size)
+		go mapKeyedWithErrorSinkWorker(wg, s, k, mp, sink, parts[i], out)
+	}
+
+	mapKeyedDispatch(s, k, key, in, parts)
+
+	wg.Wait()
+}
+
+func mapKeyedWithErrorSinkWorker[T any, K comparable, N any](wg *sync.WaitGroup, s *stop, k *keyed[K], mp func(T) (N, error), sink func(error), in <-chan pipes.KV[K, T], out chan<- N) {
+	defer wg.Done()
+
+	for kt := range in {
+		if n, er := mp(kt.Value); er != nil {
+			sink(er)
+			if s.stopping(er) {
+				// release anything left queued for this worker so the dispatcher is not blocked
+				go pipes.ChanPull[pipes.KV[K, T]](in).Drain()
+				return
+			}
+		} else {
+			out <- n
+		}
+		k.done(kt.Key)
+	}
+}
+
+// mapKeyedDispatch partitions in across parts by key until in is closed or the stage is stopped,
+// closing parts on return.
+func mapKeyedDispatch[T any, K comparable](s *stop, k *keyed[K], key func(T) K, in <-chan T, parts []chan pipes.KV[K, T]) {
+	defer func() {
+		for _, part := range parts {
+			close(part)
+		}
+	}()
+
+	for {
+		select {
+		case <-s.done:
+			go pipes.ChanPull[T](in).Drain()
+			return
+
+		case t, ok := <-in:
+			if !ok {
+				return
+			}
+
+			// the key is computed once and carried with the item for the worker to release it
+			kt := pipes.KV[K, T]{Key: key(t), Value: t}
+			select {
+			case parts[k.assign(kt.Key)] <- kt:
+			case <-s.done:
+				go pipes.ChanPull[T](in).Drain()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package async
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/curlymon/pipes"
+)
+
+type keyedItem struct {
+	key, seq int
+}
+
+func keyedItems(keys, n int) []keyedItem {
+	items := make([]keyedItem, n)
+	for i := range items {
+		items[i] = keyedItem{key: i % keys, seq: i / keys}
+	}
+	return items
+}
+
+// checkKeyedOrder fails unless the items of each key are in sequence, allowing for gaps.
+func checkKeyedOrder(t *testing.T, got []keyedItem) {
+	t.Helper()
+
+	last := make(map[int]int)
+	for _, it := range got {
+		if prev, ok := last[it.key]; ok && it.seq <= prev {
+			t.Fatalf("key %d got seq %d after %d", it.key, it.seq, prev)
+		}
+		last[it.key] = it.seq
+	}
+}
+
+func TestMapKeyedPreservesPerKeyOrder(t *testing.T) {
+	var calls atomic.Int64
+	key := func(it keyedItem) int { calls.Add(1); return it.key }
+
+	got := pipes.ToSlice(MapKeyed(4, 0, key, func(it keyedItem) keyedItem {
+		// later items of a key finish sooner, only the keyed assignment keeps them in order
+		time.Sleep(time.Duration(10-it.seq%10) * 10 * time.Microsecond)
+		return it
+	}, pipes.FromSlice(0, keyedItems(7, 700))))
+
+	if len(got) != 700 {
+		t.Fatalf("got %d items, want 700", len(got))
+	}
+	checkKeyedOrder(t, got)
+	if n := calls.Load(); n != 700 {
+		t.Fatalf("key called %d times, want once per item", n)
+	}
+}
+
+func TestMapKeyedRunsKeysInParallel(t *testing.T) {
+	started := make(chan struct{})
+
+	// the first key waits for the second to start, which only happens if they run concurrently
+	got := pipes.ToSlice(MapKeyed(2, 0, func(it keyedItem) int { return it.key }, func(it keyedItem) bool {
+		if it.key == 1 {
+			close(started)
+			return true
+		}
+		select {
+		case <-started:
+			return true
+		case <-time.After(time.Second):
+			return false
+		}
+	}, pipes.FromSlice(0, keyedItems(2, 2))))
+
+	for _, ok := range got {
+		if !ok {
+			t.Fatal("keys were processed serially")
+		}
+	}
+}
+
+func TestMapKeyedWithError(t *testing.T) {
+	fail := errors.New("fail")
+	out, errs := MapKeyedWithError(3, 0, func(it keyedItem) int { return it.key }, func(it keyedItem) (keyedItem, error) {
+		if it.seq == 5 {
+			return it, fail
+		}
+		return it, nil
+	}, pipes.FromSlice(0, keyedItems(3, 30)))
+
+	count := make(chan int)
+	go func() { count <- errs.Count() }()
+
+	got := out.ToSlice()
+	if len(got) != 27 {
+		t.Fatalf("got %d items, want 27", len(got))
+	}
+	checkKeyedOrder(t, got)
+	if n := <-count; n != 3 {
+		t.Fatalf("got %d errors, want 3", n)
+	}
+}
+
+func TestMapKeyedWithErrorStops(t *testing.T) {
+	in := make(chan keyedItem)
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for _, it := range keyedItems(3, 300) {
+			in <- it
+		}
+		close(in)
+	}()
+
+	out, errs := MapKeyedWithError(3, 0, func(it keyedItem) int { return it.key }, func(it keyedItem) (keyedItem, error) {
+		if it.seq == 5 {
+			return it, pipes.ErrStop
+		}
+		return it, nil
+	}, in)
+
+	count := make(chan int)
+	go func() { count <- out.Count() }()
+
+	for err := range errs {
+		if !errors.Is(err, pipes.ErrStop) {
+			t.Fatalf("got %v, want ErrStop", err)
+		}
+	}
+	if n := <-count; n >= 297 {
+		t.Fatalf("got %d items, want the stage to stop early", n)
+	}
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("in was not drained")
+	}
+}
+
+func TestMapKeyedWithErrorSink(t *testing.T) {
+	var mu sync.Mutex
+	var errs []error
+	sink := func(err error) { mu.Lock(); errs = append(errs, err); mu.Unlock() }
+
+	got := pipes.ToSlice(MapKeyedWithErrorSink(3, 0, func(it keyedItem) int { return it.key }, func(it keyedItem) (keyedItem, error) {
+		if it.key == 0 && it.seq%2 == 1 {
+			return it, errors.New("odd")
+		}
+		return it, nil
+	}, sink, pipes.FromSlice(0, keyedItems(3, 30))))
+
+	if len(got) != 25 {
+		t.Fatalf("got %d items, want 25", len(got))
+	}
+	checkKeyedOrder(t, got)
+	if len(errs) != 5 {
+		t.Fatalf("got %d errors, want 5", len(errs))
+	}
+}
@@ -1,7 +1,9 @@
 package async
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	"github.com/curlymon/pipes"
 )
@@ -9,14 +11,20 @@ import (
 // really the big thing that isn't obvious here is you lose any ordering going through
 // damn near everything in the package as planned lol.
 func Map[T any, N any](count, size int, mp func(T) N, in <-chan T) pipes.ChanPull[N] {
+	return MapWithMetrics(nil, "", count, size, mp, in)
+}
+
+// MapWithMetrics behaves as Map reporting the activity of every worker to m under the given stage
+// name.
+func MapWithMetrics[T any, N any](m pipes.Metrics, stage string, count, size int, mp func(T) N, in <-chan T) pipes.ChanPull[N] {
 	out := make(chan N, size)
 
-	go mapCoordinator(count, mp, in, out)
+	go mapCoordinator(pipes.NewMeter(m, stage), count, mp, in, out)
 
 	return out
 }
 
-func mapCoordinator[T any, N any](count int, mp func(T) N, in <-chan T, out chan<- N) {
+func mapCoordinator[T any, N any](mt *pipes.Meter, count int, mp func(T) N, in <-chan T, out chan<- N) {
 	defer close(out)
 
 	if count < 1 {
@@ -26,99 +34,217 @@ func mapCoordinator[T any, N any](count int, mp func(T) N, in <-chan T, out chan
 	wg := &sync.WaitGroup{}
 	wg.Add(count)
 	for ; count > 1; count-- {
-		go mapWorker(wg, mp, in, out)
+		go mapWorker(wg, mt, mp, in, out)
 	}
 
 	// demote to a worker to guarantee there is always one worker running and launch one less
 	// goroutine
-	mapWorker(wg, mp, in, out)
+	mapWorker(wg, mt, mp, in, out)
 
 	wg.Wait()
 }
 
-func mapWorker[T any, N any](wg *sync.WaitGroup, mp func(T) N, in <-chan T, out chan<- N) {
+func mapWorker[T any, N any](wg *sync.WaitGroup, mt *pipes.Meter, mp func(T) N, in <-chan T, out chan<- N) {
 	defer wg.Done()
 
 	for t := range in {
-		out <- mp(t)
+		start := mt.Received()
+		n := mp(t)
+		mt.Processed(start, nil)
+		pipes.MeterSend(mt, out, n)
 	}
 }
 
 func MapWithError[T any, N any](count, size int, mp func(T) (N, error), in <-chan T) (pipes.ChanPull[N], pipes.ChanPull[error]) {
+	return MapWithErrorMetrics(nil, "", count, size, mp, in)
+}
+
+// MapWithErrorMetrics behaves as MapWithError reporting the activity of every worker to m under the
+// given stage name.
+func MapWithErrorMetrics[T any, N any](m pipes.Metrics, stage string, count, size int, mp func(T) (N, error), in <-chan T) (pipes.ChanPull[N], pipes.ChanPull[error]) {
 	out, err := make(chan N, size), make(chan error, size)
 
-	go mapWithErrorCoordinator(count, mp, in, out, err)
+	go mapWithErrorCoordinator(pipes.NewMeter(m, stage), count, mp, in, out, err)
 
 	return out, err
 }
 
-func mapWithErrorCoordinator[T any, N any](count int, mp func(T) (N, error), in <-chan T, out chan<- N, err chan<- error) {
+func mapWithErrorCoordinator[T any, N any](mt *pipes.Meter, count int, mp func(T) (N, error), in <-chan T, out chan<- N, err chan<- error) {
 	defer func() { close(out); close(err) }()
 
 	if count < 1 {
 		count = 1
 	}
 
+	s := newStop()
 	wg := &sync.WaitGroup{}
 	wg.Add(count)
 	for ; count > 1; count-- {
-		go mapWithErrorWorker(wg, mp, in, out, err)
+		go mapWithErrorWorker(wg, s, mt, mp, in, out, err)
 	}
 
 	// demote to a worker to guarantee there is always one worker running and launch one less
 	// goroutine
-	mapWithErrorWorker(wg, mp, in, out, err)
+	mapWithErrorWorker(wg, s, mt, mp, in, out, err)
 
 	wg.Wait()
+	release(s, in)
 }
 
-func mapWithErrorWorker[T any, N any](wg *sync.WaitGroup, mp func(T) (N, error), in <-chan T, out chan<- N, err chan<- error) {
+func mapWithErrorWorker[T any, N any](wg *sync.WaitGroup, s *stop, mt *pipes.Meter, mp func(T) (N, error), in <-chan T, out chan<- N, err chan<- error) {
 	defer wg.Done()
 
-	for t := range in {
-		if n, er := mp(t); er != nil {
-			err <- er
-		} else {
-			out <- n
+	for {
+		select {
+		case <-s.done:
+			return
+
+		case t, ok := <-in:
+			if !ok {
+				return
+			}
+
+			start := mt.Received()
+			n, er := mp(t)
+			mt.Processed(start, er)
+			if er != nil {
+				err <- er
+				if s.stopping(er) {
+					return
+				}
+			} else {
+				pipes.MeterSend(mt, out, n)
+			}
 		}
 	}
 }
 
 func MapWithErrorSink[T any, N any](count, size int, mp func(T) (N, error), sink func(error), in <-chan T) pipes.ChanPull[N] {
+	return MapWithErrorSinkMetrics(nil, "", count, size, mp, sink, in)
+}
+
+// MapWithErrorSinkMetrics behaves as MapWithErrorSink reporting the activity of every worker to m
+// under the given stage name.
+func MapWithErrorSinkMetrics[T any, N any](m pipes.Metrics, stage string, count, size int, mp func(T) (N, error), sink func(error), in <-chan T) pipes.ChanPull[N] {
 	out := make(chan N, size)
 
-	go mapWithErrorSinkCoordinator(count, mp, sink, in, out)
+	go mapWithErrorSinkCoordinator(pipes.NewMeter(m, stage), count, mp, sink, in, out)
 
 	return out
 }
 
-func mapWithErrorSinkCoordinator[T any, N any](count int, mp func(T) (N, error), sink func(error), in <-chan T, out chan<- N) {
+func mapWithErrorSinkCoordinator[T any, N any](mt *pipes.Meter, count int, mp func(T) (N, error), sink func(error), in <-chan T, out chan<- N) {
 	defer close(out)
 
 	if count < 1 {
 		count = 1
 	}
 
+	s := newStop()
 	wg := &sync.WaitGroup{}
 	wg.Add(count)
 	for ; count > 1; count-- {
-		go mapWithErrorSinkWorker(wg, mp, sink, in, out)
+		go mapWithErrorSinkWorker(wg, s, mt, mp, sink, in, out)
 	}
 
 	// demote to a worker to guarantee there is always one worker running and launch only `count`
 	// goroutines
-	mapWithErrorSinkWorker(wg, mp, sink, in, out)
+	mapWithErrorSinkWorker(wg, s, mt, mp, sink, in, out)
 
 	wg.Wait()
+	release(s, in)
 }
 
-func mapWithErrorSinkWorker[T any, N any](wg *sync.WaitGroup, mp func(T) (N, error), sink func(error), in <-chan T, out chan<- N) {
+func mapWithErrorSinkWorker[T any, N any](wg *sync.WaitGroup, s *stop, mt *pipes.Meter, mp func(T) (N, error), sink func(error), in <-chan T, out chan<- N) {
 	defer wg.Done()
-	for t := range in {
-		if n, er := mp(t); er != nil {
-			sink(er)
-		} else {
-			out <- n
+
+	for {
+		select {
+		case <-s.done:
+			return
+
+		case t, ok := <-in:
+			if !ok {
+				return
+			}
+
+			start := mt.Received()
+			n, er := mp(t)
+			mt.Processed(start, er)
+			if er != nil {
+				sink(er)
+				if s.stopping(er) {
+					return
+				}
+			} else {
+				pipes.MeterSend(mt, out, n)
+			}
+		}
+	}
+}
+
+// MapWithShutdown behaves as Map while participating in the graceful shutdown coordinated by s. On
+// abandonment any items held by workers, or left buffered in in, are recorded as dropped by stage.
+func MapWithShutdown[T any, N any](s *pipes.Shutdown, stage string, count, size int, mp func(T) N, in <-chan T) pipes.ChanPull[N] {
+	out := make(chan N, size)
+
+	s.Add(1)
+	go mapWithShutdownCoordinator(s, stage, count, mp, in, out)
+
+	return out
+}
+
+func mapWithShutdownCoordinator[T any, N any](s *pipes.Shutdown, stage string, count int, mp func(T) N, in <-chan T, out chan<- N) {
+	defer s.Done()
+	defer close(out)
+
+	if count < 1 {
+		count = 1
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(count)
+	for ; count > 1; count-- {
+		go mapWithShutdownWorker(wg, s, stage, mp, in, out)
+	}
+
+	// demote to a worker to guarantee there is always one worker running and launch one less
+	// goroutine
+	mapWithShutdownWorker(wg, s, stage, mp, in, out)
+
+	wg.Wait()
+
+	select {
+	case <-s.Abandoned():
+		s.Drop(stage, len(in))
+	default:
+	}
+}
+
+func mapWithShutdownWorker[T any, N any](wg *sync.WaitGroup, s *pipes.Shutdown, stage string, mp func(T) N, in <-chan T, out chan<- N) {
+	defer wg.Done()
+
+	for {
+		select {
+		case <-s.Abandoned():
+			return
+
+		case t, ok := <-in:
+			if !ok {
+				return
+			}
+
+			if !pipes.Send(s, stage, out, mp(t)) {
+				return
+			}
 		}
 	}
 }
+
+func MapWithTimeout[T any, N any](count, size int, timeout time.Duration, mp func(context.Context, T) (N, error), in <-chan T) (pipes.ChanPull[N], pipes.ChanPull[error]) {
+	return MapWithError(count, size, pipes.TimeoutMap(timeout, mp), in)
+}
+
+func MapWithTimeoutErrorSink[T any, N any](count, size int, timeout time.Duration, mp func(context.Context, T) (N, error), sink func(error), in <-chan T) pipes.ChanPull[N] {
+	return MapWithErrorSink(count, size, pipes.TimeoutMap(timeout, mp), sink, in)
+}
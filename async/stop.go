@@ -0,0 +1,40 @@
+package async
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/curlymon/pipes"
+)
+
+// stop coordinates shutting down every worker of a stage once any one of them observes
+// pipes.ErrStop.
+type stop struct {
+	once sync.Once
+	done chan struct{}
+}
+
+func newStop() *stop {
+	return &stop{done: make(chan struct{})}
+}
+
+// stopping reports whether err requests the stage to stop, signalling every worker when it does.
+func (s *stop) stopping(err error) bool {
+	if !errors.Is(err, pipes.ErrStop) {
+		return false
+	}
+
+	s.once.Do(func() { close(s.done) })
+
+	return true
+}
+
+// release drains the remainder of in in the background if the stage was stopped, this releases
+// any upstream stages still pushing to it.
+func release[T any](s *stop, in <-chan T) {
+	select {
+	case <-s.done:
+		go pipes.ChanPull[T](in).Drain()
+	default:
+	}
+}
@@ -0,0 +1,130 @@
+package async
+
+import (
+	"context"
+	"sync"
+
+	"github.com/curlymon/pipes"
+)
+
+// MapWithTracer behaves as Map over Traced items, every worker covering each call to mp with a
+// span for the given stage. The span's context is passed to mp and carried on to the result.
+func MapWithTracer[T any, N any](tracer pipes.Tracer, stage string, count, size int, mp func(context.Context, T) N, in <-chan pipes.Traced[T]) pipes.ChanPull[pipes.Traced[N]] {
+	out := make(chan pipes.Traced[N], size)
+
+	go mapWithTracerCoordinator(tracer, stage, count, mp, in, out)
+
+	return out
+}
+
+func mapWithTracerCoordinator[T any, N any](tracer pipes.Tracer, stage string, count int, mp func(context.Context, T) N, in <-chan pipes.Traced[T], out chan<- pipes.Traced[N]) {
+	defer close(out)
+
+	if count < 1 {
+		count = 1
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(count)
+	for ; count > 1; count-- {
+		go mapWithTracerWorker(wg, tracer, stage, mp, in, out)
+	}
+
+	// demote to a worker to guarantee there is always one worker running and launch one less
+	// goroutine
+	mapWithTracerWorker(wg, tracer, stage, mp, in, out)
+
+	wg.Wait()
+}
+
+func mapWithTracerWorker[T any, N any](wg *sync.WaitGroup, tracer pipes.Tracer, stage string, mp func(context.Context, T) N, in <-chan pipes.Traced[T], out chan<- pipes.Traced[N]) {
+	defer wg.Done()
+
+	for t := range in {
+		ctx, span := tracer.Start(t.Ctx, stage)
+		n := mp(ctx, t.Value)
+		span.End(nil)
+		out <- pipes.Traced[N]{Ctx: ctx, Value: n}
+	}
+}
+
+// MapWithErrorTracer behaves as MapWithError over Traced items, every worker covering each call to
+// mp with a span for the given stage ended with any error returned.
+func MapWithErrorTracer[T any, N any](tracer pipes.Tracer, stage string, count, size int, mp func(context.Context, T) (N, error), in <-chan pipes.Traced[T]) (pipes.ChanPull[pipes.Traced[N]], pipes.ChanPull[error]) {
+	out, err := make(chan pipes.Traced[N], size), make(chan error, size)
+
+	go mapWithErrorTracerCoordinator(tracer, stage, count, mp, nil, in, out, err)
+
+	return out, err
+}
+
+// MapWithErrorSinkTracer behaves as MapWithErrorSink over Traced items, every worker covering each
+// call to mp with a span for the given stage ended with any error returned.
+func MapWithErrorSinkTracer[T any, N any](tracer pipes.Tracer, stage string, count, size int, mp func(context.Context, T) (N, error), sink func(error), in <-chan pipes.Traced[T]) pipes.ChanPull[pipes.Traced[N]] {
+	out := make(chan pipes.Traced[N], size)
+
+	go mapWithErrorTracerCoordinator(tracer, stage, count, mp, sink, in, out, nil)
+
+	return out
+}
+
+// mapWithErrorTracerCoordinator runs the workers of both MapWithErrorTracer, passing errors to err,
+// and MapWithErrorSinkTracer, passing them to sink.
+func mapWithErrorTracerCoordinator[T any, N any](tracer pipes.Tracer, stage string, count int, mp func(context.Context, T) (N, error), sink func(error), in <-chan pipes.Traced[T], out chan<- pipes.Traced[N], err chan<- error) {
+	defer func() {
+		close(out)
+		if err != nil {
+			close(err)
+		}
+	}()
+
+	if sink == nil {
+		sink = func(er error) { err <- er }
+	}
+
+	if count < 1 {
+		count = 1
+	}
+
+	s := newStop()
+	wg := &sync.WaitGroup{}
+	wg.Add(count)
+	for ; count > 1; count-- {
+		go mapWithErrorTracerWorker(wg, s, tracer, stage, mp, sink, in, out)
+	}
+
+	// demote to a worker to guarantee there is always one worker running and launch one less
+	// goroutine
+	mapWithErrorTracerWorker(wg, s, tracer, stage, mp, sink, in, out)
+
+	wg.Wait()
+	release(s, in)
+}
+
+func mapWithErrorTracerWorker[T any, N any](wg *sync.WaitGroup, s *stop, tracer pipes.Tracer, stage string, mp func(context.Context, T) (N, error), sink func(error), in <-chan pipes.Traced[T], out chan<- pipes.Traced[N]) {
+	defer wg.Done()
+
+	for {
+		select {
+		case <-s.done:
+			return
+
+		case t, ok := <-in:
+			if !ok {
+				return
+			}
+
+			ctx, span := tracer.Start(t.Ctx, stage)
+			n, er := mp(ctx, t.Value)
+			span.End(er)
+			if er != nil {
+				sink(er)
+				if s.stopping(er) {
+					return
+				}
+			} else {
+				out <- pipes.Traced[N]{Ctx: ctx, Value: n}
+			}
+		}
+	}
+}
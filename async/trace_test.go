@@ -0,0 +1,88 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/curlymon/pipes"
+)
+
+type countingSpan struct {
+	t *countingTracer
+}
+
+func (s *countingSpan) End(err error) {
+	s.t.mu.Lock()
+	defer s.t.mu.Unlock()
+
+	s.t.ended++
+	if err != nil {
+		s.t.failed++
+	}
+}
+
+// countingTracer counts the spans started, ended and ended with an error.
+type countingTracer struct {
+	mu                     sync.Mutex
+	started, ended, failed int
+}
+
+func (c *countingTracer) Start(ctx context.Context, _ string) (context.Context, pipes.Span) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.started++
+	return ctx, &countingSpan{t: c}
+}
+
+func TestMapWithTracer(t *testing.T) {
+	tracer := &countingTracer{}
+
+	out := MapWithTracer(tracer, "square", 4, 0, func(_ context.Context, i int) int { return i * i }, pipes.Trace(0, pipes.Range(0, 0, 100, 1)))
+	got := pipes.ToSlice(pipes.Untrace(0, out))
+	slices.Sort(got)
+
+	if len(got) != 100 || got[99] != 99*99 {
+		t.Fatalf("got %d items ending %d, want 100 squares", len(got), got[len(got)-1])
+	}
+	if tracer.started != 100 || tracer.ended != 100 || tracer.failed != 0 {
+		t.Fatalf("got %+v, want 100 spans started and ended", tracer)
+	}
+}
+
+func TestMapWithErrorTracer(t *testing.T) {
+	tracer := &countingTracer{}
+
+	errOdd := errors.New("odd")
+	out, errs := MapWithErrorTracer(tracer, "even", 4, 0, func(_ context.Context, i int) (int, error) {
+		if i%2 != 0 {
+			return 0, errOdd
+		}
+		return i, nil
+	}, pipes.Trace(0, pipes.Range(0, 0, 100, 1)))
+
+	count := make(chan int)
+	go func() { count <- errs.Count() }()
+
+	if n := out.Count(); n != 50 {
+		t.Fatalf("got %d items, want 50", n)
+	}
+	if n := <-count; n != 50 {
+		t.Fatalf("got %d errors, want 50", n)
+	}
+	if tracer.ended != 100 || tracer.failed != 50 {
+		t.Fatalf("got %+v, want 100 spans ended, 50 with an error", tracer)
+	}
+
+	var sunk atomic.Int64
+	sinkOut := MapWithErrorSinkTracer(tracer, "even", 4, 0, func(_ context.Context, i int) (int, error) {
+		return 0, errOdd
+	}, func(error) { sunk.Add(1) }, pipes.Trace(0, pipes.Range(0, 0, 10, 1)))
+	if n := sinkOut.Count(); n != 0 || sunk.Load() != 10 {
+		t.Fatalf("got %d items and %d errors sunk, want none and 10", n, sunk.Load())
+	}
+}
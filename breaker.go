@@ -0,0 +1,352 @@
+package pipes
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBreakerOpen is returned in place of calling a function guarded by an open Breaker when no
+// fallback is given.
+var ErrBreakerOpen = errors.New("pipes: circuit breaker open")
+
+// errBreakerPanic is recorded as the result of a guarded call that panicked.
+var errBreakerPanic = errors.New("pipes: circuit breaker guarded call panicked")
+
+// BreakerState is the state of a Breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed allows all calls through while counting failures.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen short circuits all calls until the cooldown has passed.
+	BreakerOpen
+	// BreakerHalfOpen allows a single trial call through at a time to decide whether to close or
+	// re-open.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerConfig configures a Breaker. At least one of ConsecutiveFailures or FailureRate must be
+// set for the Breaker to ever open.
+type BreakerConfig struct {
+	// ConsecutiveFailures opens the breaker after this many failures in a row, 0 disables.
+	ConsecutiveFailures int
+	// FailureRate opens the breaker once the ratio of failures within the last Window calls reaches
+	// this value, 0 disables. The rate is only evaluated once Window calls have been made.
+	FailureRate float64
+	// Window is the number of most recent calls FailureRate is evaluated over. Defaults to 10.
+	Window int
+	// Cooldown is how long the breaker stays open before allowing a trial call. Defaults to 1s.
+	Cooldown time.Duration
+	// HalfOpenSuccesses is the number of successful trial calls needed to close the breaker again.
+	// Defaults to 1.
+	HalfOpenSuccesses int
+	// OnStateChange, if set, is called after each state transition. It is called synchronously from
+	// the goroutine that caused the transition and must not call back into the Breaker.
+	OnStateChange func(from, to BreakerState)
+}
+
+// Breaker is a circuit breaker that short circuits calls to a failing dependency. It is safe for
+// concurrent use and may be shared between stages guarding the same dependency.
+type Breaker struct {
+	cfg BreakerConfig
+
+	mu          sync.Mutex
+	state       BreakerState
+	generation  uint64 // incremented on every transition, tagging calls with the state they began in
+	openedAt    time.Time
+	consecutive int
+	results     []bool // ring of the most recent results, true being a failure
+	next        int
+	filled      bool
+	failures    int
+	trial       bool // a half-open trial call is in flight
+	successes   int
+}
+
+// NewBreaker returns a new closed Breaker configured by cfg.
+func NewBreaker(cfg BreakerConfig) *Breaker {
+	if cfg.Window < 1 {
+		cfg.Window = 10
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = time.Second
+	}
+	if cfg.HalfOpenSuccesses < 1 {
+		cfg.HalfOpenSuccesses = 1
+	}
+
+	return &Breaker{
+		cfg:     cfg,
+		results: make([]bool, cfg.Window),
+	}
+}
+
+// State returns the current state of the Breaker.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cfg.Cooldown {
+		return BreakerHalfOpen
+	}
+
+	return b.state
+}
+
+// Allow reports whether a call may proceed, along with the generation of the state it proceeds in.
+// Every call allowed must be followed by a call to Done with that generation and its result, even
+// if the call panics.
+func (b *Breaker) Allow() (generation uint64, allowed bool) {
+	b.mu.Lock()
+	from := b.state
+	switch b.state {
+	case BreakerClosed:
+		allowed = true
+
+	case BreakerOpen:
+		if time.Since(b.openedAt) >= b.cfg.Cooldown {
+			b.transition(BreakerHalfOpen)
+			b.trial, b.successes = true, 0
+			allowed = true
+		}
+
+	case BreakerHalfOpen:
+		if !b.trial {
+			b.trial = true
+			allowed = true
+		}
+	}
+	to, generation := b.state, b.generation
+	b.mu.Unlock()
+
+	b.notify(from, to)
+
+	return generation, allowed
+}
+
+// Done records the result of a call previously allowed by Allow in the given generation. Results
+// of calls that began before the latest transition are ignored, so a slow call started while closed
+// is not mistaken for the result of a half-open trial.
+func (b *Breaker) Done(generation uint64, err error) {
+	b.mu.Lock()
+	if generation != b.generation {
+		b.mu.Unlock()
+		return
+	}
+
+	from := b.state
+	switch b.state {
+	case BreakerClosed:
+		b.record(err != nil)
+		if b.tripped() {
+			b.open()
+		}
+
+	case BreakerHalfOpen:
+		b.trial = false
+		if err != nil {
+			b.open()
+		} else if b.successes++; b.successes >= b.cfg.HalfOpenSuccesses {
+			b.close()
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+// record adds a result to the failure counters. The caller must hold mu.
+func (b *Breaker) record(failed bool) {
+	if failed {
+		b.consecutive++
+	} else {
+		b.consecutive = 0
+	}
+
+	if b.results[b.next] {
+		b.failures--
+	}
+	b.results[b.next] = failed
+	if failed {
+		b.failures++
+	}
+
+	if b.next++; b.next == len(b.results) {
+		b.next, b.filled = 0, true
+	}
+}
+
+// tripped reports whether the failure thresholds have been reached. The caller must hold mu.
+func (b *Breaker) tripped() bool {
+	if b.cfg.ConsecutiveFailures > 0 && b.consecutive >= b.cfg.ConsecutiveFailures {
+		return true
+	}
+
+	return b.cfg.FailureRate > 0 && b.filled &&
+		float64(b.failures)/float64(len(b.results)) >= b.cfg.FailureRate
+}
+
+// transition moves to state starting a new generation. The caller must hold mu.
+func (b *Breaker) transition(state BreakerState) {
+	b.state = state
+	b.generation++
+}
+
+// open transitions to BreakerOpen. The caller must hold mu.
+func (b *Breaker) open() {
+	b.transition(BreakerOpen)
+	b.openedAt, b.trial = time.Now(), false
+}
+
+// close transitions to BreakerClosed resetting all counters. The caller must hold mu.
+func (b *Breaker) close() {
+	b.transition(BreakerClosed)
+	b.consecutive, b.failures, b.next, b.filled = 0, 0, 0, false
+	for i := range b.results {
+		b.results[i] = false
+	}
+}
+
+// call runs fn recording its result with b under generation. A panic is recorded as a failure
+// before it continues to unwind, so a half-open trial slot is never left taken.
+func (b *Breaker) call(generation uint64, fn func() error) error {
+	recorded := false
+	defer func() {
+		if !recorded {
+			b.Done(generation, errBreakerPanic)
+		}
+	}()
+
+	err := fn()
+	recorded = true
+	b.Done(generation, err)
+
+	return err
+}
+
+func (b *Breaker) notify(from, to BreakerState) {
+	if from != to && b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(from, to)
+	}
+}
+
+// BreakerMap guards mp with b. While b is open items are passed to fallback instead, or if fallback
+// is nil ErrBreakerOpen is returned. The returned function can be passed to MapWithError,
+// MapWithErrorSink or their async counterparts.
+func BreakerMap[T any, N any](b *Breaker, mp func(T) (N, error), fallback func(T) (N, error)) func(T) (N, error) {
+	return func(t T) (N, error) {
+		generation, ok := b.Allow()
+		if !ok {
+			if fallback == nil {
+				var n N
+				return n, ErrBreakerOpen
+			}
+			return fallback(t)
+		}
+
+		var n N
+		err := b.call(generation, func() (err error) {
+			n, err = mp(t)
+			return err
+		})
+		return n, err
+	}
+}
+
+// BreakerSink guards sink with b. While b is open items are passed to fallback instead, or if
+// fallback is nil ErrBreakerOpen is returned. The returned function can be passed to
+// SinkWithError, SinkWithErrorSink, TapWithError or TapWithErrorSink.
+func BreakerSink[T any](b *Breaker, sink func(T) error, fallback func(T) error) func(T) error {
+	return func(t T) error {
+		generation, ok := b.Allow()
+		if !ok {
+			if fallback == nil {
+				return ErrBreakerOpen
+			}
+			return fallback(t)
+		}
+
+		return b.call(generation, func() error { return sink(t) })
+	}
+}
+
+// MapWithBreaker behaves as MapWithError with mp guarded by b. While b is open items are pushed
+// unprocessed onto the returned rejected channel.
+func MapWithBreaker[T any, N any](size int, b *Breaker, mp func(T) (N, error), in <-chan T) (ChanPull[N], ChanPull[T], ChanPull[error]) {
+	out, rejected, err := make(chan N, size), make(chan T, size), make(chan error, size)
+
+	go mapWithBreakerWorker(b, mp, in, out, rejected, err)
+
+	return out, rejected, err
+}
+
+func mapWithBreakerWorker[T any, N any](b *Breaker, mp func(T) (N, error), in <-chan T, out chan<- N, rejected chan<- T, err chan<- error) {
+	defer func() { close(out); close(rejected); close(err) }()
+
+	for t := range in {
+		generation, ok := b.Allow()
+		if !ok {
+			rejected <- t
+			continue
+		}
+
+		var n N
+		er := b.call(generation, func() (er error) {
+			n, er = mp(t)
+			return er
+		})
+		if er != nil {
+			err <- er
+			if stopping(er, in) {
+				return
+			}
+		} else {
+			out <- n
+		}
+	}
+}
+
+// SinkWithBreaker behaves as SinkWithError with sink guarded by b. While b is open items are pushed
+// onto the returned rejected channel instead.
+func SinkWithBreaker[T any](size int, b *Breaker, sink func(T) error, in <-chan T) (ChanPull[T], ChanPull[error]) {
+	rejected, err := make(chan T, size), make(chan error, size)
+
+	go sinkWithBreakerWorker(b, sink, in, rejected, err)
+
+	return rejected, err
+}
+
+func sinkWithBreakerWorker[T any](b *Breaker, sink func(T) error, in <-chan T, rejected chan<- T, err chan<- error) {
+	defer func() { close(rejected); close(err) }()
+
+	for t := range in {
+		generation, ok := b.Allow()
+		if !ok {
+			rejected <- t
+			continue
+		}
+
+		er := b.call(generation, func() error { return sink(t) })
+		if er != nil {
+			err <- er
+			if stopping(er, in) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package pipes
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreakerPanicReleasesHalfOpenTrial(t *testing.T) {
+	b := NewBreaker(BreakerConfig{ConsecutiveFailures: 1, Cooldown: time.Millisecond})
+	fail := errors.New("fail")
+
+	guarded := BreakerMap(b, func(i int) (int, error) {
+		switch i {
+		case 0:
+			return 0, fail
+		case 1:
+			panic("boom")
+		}
+		return i, nil
+	}, nil)
+
+	if _, err := guarded(0); err != fail {
+		t.Fatalf("got %v, want fail", err)
+	}
+	if b.State() != BreakerOpen {
+		t.Fatalf("got %s, want open", b.State())
+	}
+
+	// the half-open trial panics
+	time.Sleep(2 * time.Millisecond)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected the panic to propagate")
+			}
+		}()
+		guarded(1)
+	}()
+
+	// the panic re-opened the breaker rather than leaving the trial slot taken forever
+	time.Sleep(2 * time.Millisecond)
+	if n, err := guarded(2); err != nil || n != 2 {
+		t.Fatalf("got %d, %v, want 2, nil", n, err)
+	}
+	if b.State() != BreakerClosed {
+		t.Fatalf("got %s, want closed", b.State())
+	}
+}
+
+func TestBreakerIgnoresResultsFromEarlierState(t *testing.T) {
+	b := NewBreaker(BreakerConfig{ConsecutiveFailures: 1, Cooldown: time.Millisecond})
+
+	// a slow call starts while closed
+	slow, ok := b.Allow()
+	if !ok {
+		t.Fatal("closed breaker rejected a call")
+	}
+
+	gen, _ := b.Allow()
+	b.Done(gen, errors.New("fail"))
+
+	time.Sleep(2 * time.Millisecond)
+	trial, ok := b.Allow()
+	if !ok || b.State() != BreakerHalfOpen {
+		t.Fatalf("got %v %s, want the trial allowed while half-open", ok, b.State())
+	}
+
+	// the slow call finishing successfully must not count as the trial
+	b.Done(slow, nil)
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("got %s after a stale result, want half-open", b.State())
+	}
+	if _, ok := b.Allow(); ok {
+		t.Fatal("a second trial was allowed while the first is in flight")
+	}
+
+	b.Done(trial, nil)
+	if b.State() != BreakerClosed {
+		t.Fatalf("got %s, want closed", b.State())
+	}
+}
+
+func TestBreakerFailureRateOverWindow(t *testing.T) {
+	b := NewBreaker(BreakerConfig{FailureRate: 0.75, Window: 4, Cooldown: time.Hour})
+	fail := errors.New("fail")
+
+	call := func(err error) BreakerState {
+		t.Helper()
+
+		gen, ok := b.Allow()
+		if !ok {
+			t.Fatal("closed breaker rejected a call")
+		}
+		b.Done(gen, err)
+		return b.State()
+	}
+
+	// the rate is not evaluated until the window is full, and the failures of the first two calls
+	// fall out of the window as it rolls
+	for i, err := range []error{fail, fail, nil, nil, nil, nil, fail, fail} {
+		if state := call(err); state != BreakerClosed {
+			t.Fatalf("call %d got %s, want closed", i, state)
+		}
+	}
+
+	if state := call(fail); state != BreakerOpen {
+		t.Fatalf("got %s, want open at 3 failures in the last 4 calls", state)
+	}
+}
@@ -1,6 +1,10 @@
 package pipes
 
-import "time"
+import (
+	"context"
+	"iter"
+	"time"
+)
 
 type Chan[T any] chan T
 
@@ -76,6 +80,17 @@ func (c Chan[T]) Wait() {
 	<-c
 }
 
+// All returns an iterator yielding each T read from the channel until it is closed. This allows
+// ranging over the channel alongside the standard slices and maps packages, see Seq.
+func (c Chan[T]) All() iter.Seq[T] {
+	return Seq(c)
+}
+
+// Seq is the method form of the Seq function and is equivalent to All.
+func (c Chan[T]) Seq() iter.Seq[T] {
+	return Seq(c)
+}
+
 func (c Chan[T]) FanOut(count, size int) []ChanPull[T] {
 	return FanOut(count, size, c)
 }
@@ -117,6 +132,14 @@ func (c Chan[T]) MapWithErrorSink(size int, mp func(T) (any, error), sink func(e
 	return MapWithErrorSink(size, mp, sink, c)
 }
 
+// FlatMap returns any as the type we transform to here due to generics not supporting method
+// parameterization. If you need type safety here use the `FlatMap` function directly.
+//
+// ref: https://go.googlesource.com/proposal/+/refs/heads/master/design/43651-type-parameters.md#No-parameterized-methods
+func (c Chan[T]) FlatMap(size int, mp func(T) []any) ChanPull[any] {
+	return FlatMap(size, mp, c)
+}
+
 // Reduce returns any as the type we transform to here due to generics not supporting method
 // parameterization. If you need type safety here use the `Reduce` function directly.
 //
@@ -133,6 +156,14 @@ func (c Chan[T]) ReduceAndEmit(reduce func(T, any) any, acc any, in <-chan T) Ch
 	return ReduceAndEmit(reduce, acc, c)
 }
 
+// Scan returns any as the type we transform to here due to generics not supporting method
+// parameterization. If you need type safety here use the `Scan` function directly.
+//
+// ref: https://go.googlesource.com/proposal/+/refs/heads/master/design/43651-type-parameters.md#No-parameterized-methods
+func (c Chan[T]) Scan(size int, reduce func(T, any) any, acc any) ChanPull[any] {
+	return Scan(size, reduce, acc, c)
+}
+
 // Window returns any as the type we transform to here due to generics not supporting method
 // parameterization. If you need type safety here use the `Window` function directly.
 //
@@ -141,6 +172,22 @@ func (c Chan[T]) Window(size int, window time.Duration, reduce func(T, any) any,
 	return Window(size, window, reduce, acc, c)
 }
 
+func (c Chan[T]) Debounce(size int, quiet time.Duration, clock Clock) ChanPull[T] {
+	return Debounce(size, quiet, clock, c)
+}
+
+func (c Chan[T]) ThrottleFirst(size int, interval time.Duration, clock Clock) ChanPull[T] {
+	return ThrottleFirst(size, interval, clock, c)
+}
+
+func (c Chan[T]) Sample(size int, interval time.Duration, clock Clock) ChanPull[T] {
+	return Sample(size, interval, clock, c)
+}
+
+func (c Chan[T]) Audit(size int, interval time.Duration, clock Clock) ChanPull[T] {
+	return Audit(size, interval, clock, c)
+}
+
 func (c Chan[T]) RoundRobin(size, count int) []ChanPull[T] {
 	return RoundRobin(size, count, c)
 }
@@ -161,6 +208,58 @@ func (c Chan[T]) SinkWithErrorSink(sink func(T) error, errSink func(error)) {
 	SinkWithErrorSink(sink, errSink, c)
 }
 
+func (c Chan[T]) ToSlice() []T {
+	return ToSlice(c)
+}
+
+func (c Chan[T]) Count() int {
+	return Count(c)
+}
+
+func (c Chan[T]) Take(size, n int) ChanPull[T] {
+	return Take(size, n, c)
+}
+
+func (c Chan[T]) TakeWithCancel(size, n int, cancel context.CancelFunc) ChanPull[T] {
+	return TakeWithCancel(size, n, cancel, c)
+}
+
+func (c Chan[T]) Skip(size, n int) ChanPull[T] {
+	return Skip(size, n, c)
+}
+
+func (c Chan[T]) TakeWhile(size int, keep func(T) bool) ChanPull[T] {
+	return TakeWhile(size, keep, c)
+}
+
+func (c Chan[T]) TakeWhileWithCancel(size int, keep func(T) bool, cancel context.CancelFunc) ChanPull[T] {
+	return TakeWhileWithCancel(size, keep, cancel, c)
+}
+
+func (c Chan[T]) SkipWhile(size int, skip func(T) bool) ChanPull[T] {
+	return SkipWhile(size, skip, c)
+}
+
+func (c Chan[T]) TakeUntil(size int, signal <-chan struct{}) ChanPull[T] {
+	return TakeUntil(size, signal, c)
+}
+
+func (c Chan[T]) TakeUntilWithCancel(size int, signal <-chan struct{}, cancel context.CancelFunc) ChanPull[T] {
+	return TakeUntilWithCancel(size, signal, cancel, c)
+}
+
+func (c Chan[T]) First() (T, bool) {
+	return First(c)
+}
+
+func (c Chan[T]) FirstWithCancel(cancel context.CancelFunc) (T, bool) {
+	return FirstWithCancel(cancel, c)
+}
+
+func (c Chan[T]) Last() (T, bool) {
+	return Last(c)
+}
+
 func (c Chan[T]) Tap(size int, tap func(T)) ChanPull[T] {
 	return Tap(size, tap, c)
 }
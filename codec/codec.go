@@ -0,0 +1,59 @@
+// Package codec provides stages decoding and encoding streams of records in common formats.
+package codec
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// RecordError reports an error decoding or encoding a single record. Decoding continues past a
+// RecordError wherever the format allows it.
+type RecordError struct {
+	// Line is the 1 based line number of the record, or for formats without lines such as gob the
+	// 1 based record number.
+	Line int
+	Err  error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("codec: record %d: %s", e.Line, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// Encoder encodes values onto a stream, as implemented by json.Encoder and gob.Encoder.
+type Encoder interface {
+	Encode(v any) error
+}
+
+// Decoder decodes values from a stream, as implemented by json.Decoder and gob.Decoder.
+type Decoder interface {
+	Decode(v any) error
+}
+
+// Codec creates the Encoder and Decoder pair of a stream format, allowing stages that move values
+// across a process boundary to be independent of the format used.
+type Codec interface {
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+// JSON is a Codec streaming values as JSON.
+var JSON Codec = jsonCodec{}
+
+// Gob is a Codec streaming values using encoding/gob.
+var Gob Codec = gobCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) NewEncoder(w io.Writer) Encoder { return json.NewEncoder(w) }
+func (jsonCodec) NewDecoder(r io.Reader) Decoder { return json.NewDecoder(r) }
+
+type gobCodec struct{}
+
+func (gobCodec) NewEncoder(w io.Writer) Encoder { return gob.NewEncoder(w) }
+func (gobCodec) NewDecoder(r io.Reader) Decoder { return gob.NewDecoder(r) }
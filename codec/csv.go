@@ -0,0 +1,385 @@
+package codec
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/curlymon/pipes"
+)
+
+// DecodeCSVWithError decodes each record read from r into a T, closing the returned channel once r
+// is exhausted. T must be a struct, the first record of r is a header naming the column each field
+// is read from. A field's column is named by its `csv` struct tag, or failing that matched to the
+// field name ignoring case, a tag of "-" skips the field. Columns without a matching field are
+// ignored. Records that fail to parse or decode are reported as a *RecordError and skipped, while
+// an error reading r is reported and ends the stream.
+//
+// Fields may be strings, bools, integers, floats, time.Duration or implement
+// encoding.TextUnmarshaler, or be pointers to them left nil for an empty cell.
+func DecodeCSVWithError[T any](size int, r io.Reader) (pipes.ChanPull[T], pipes.ChanPull[error]) {
+	out, err := make(chan T, size), make(chan error, size)
+
+	go decodeCSVWithErrorWorker(r, out, err)
+
+	return out, err
+}
+
+func decodeCSVWithErrorWorker[T any](r io.Reader, out chan<- T, err chan<- error) {
+	defer func() { close(out); close(err) }()
+
+	decodeCSVWorker(r, func(er error) { err <- er }, out)
+}
+
+// DecodeCSVWithErrorSink behaves as DecodeCSVWithError passing errors to sink.
+func DecodeCSVWithErrorSink[T any](size int, sink func(error), r io.Reader) pipes.ChanPull[T] {
+	out := make(chan T, size)
+
+	go decodeCSVWithErrorSinkWorker(r, sink, out)
+
+	return out
+}
+
+func decodeCSVWithErrorSinkWorker[T any](r io.Reader, sink func(error), out chan<- T) {
+	defer close(out)
+
+	decodeCSVWorker(r, sink, out)
+}
+
+func decodeCSVWorker[T any](r io.Reader, sink func(error), out chan<- T) {
+	fields, err := csvFieldsOf(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		sink(err)
+		return
+	}
+
+	cr := csv.NewReader(r)
+	// short and long records are tolerated, missing columns are left as the zero value
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			sink(err)
+		}
+		return
+	}
+
+	// ReuseRecord means the header would be overwritten by the next Read
+	header = append([]string(nil), header...)
+	columns := make([][]int, len(header))
+	for i, name := range header {
+		columns[i] = csvFieldIndex(fields, name)
+	}
+
+	for {
+		record, err := cr.Read()
+		if err != nil {
+			var pe *csv.ParseError
+			switch {
+			case errors.Is(err, io.EOF):
+				return
+			case errors.As(err, &pe):
+				sink(&RecordError{Line: pe.Line, Err: pe.Err})
+				continue
+			default:
+				sink(err)
+				return
+			}
+		}
+
+		var t T
+		v := reflect.ValueOf(&t).Elem()
+		if err := decodeCSVRecord(v, columns, header, record); err != nil {
+			line, _ := cr.FieldPos(0)
+			sink(&RecordError{Line: line, Err: err})
+			continue
+		}
+
+		out <- t
+	}
+}
+
+func decodeCSVRecord(v reflect.Value, columns [][]int, header, record []string) error {
+	for i, s := range record {
+		if i >= len(columns) || columns[i] == nil {
+			continue
+		}
+
+		field, err := csvFieldAlloc(v, columns[i])
+		if err == nil {
+			err = csvDecodeField(field, s)
+		}
+		if err != nil {
+			return fmt.Errorf("column %q: %w", header[i], err)
+		}
+	}
+
+	return nil
+}
+
+// EncodeCSVWithError writes each T read from in to w as a CSV record, preceded by a header record
+// naming each column. T must be a struct, see DecodeCSVWithError for how fields are mapped to
+// columns. Fields may be strings, bools, integers, floats, time.Duration or implement
+// encoding.TextMarshaler, or be pointers to them. Nil pointers, including embedded ones, are
+// written as an empty cell. Items that fail to encode are reported as a *RecordError and skipped.
+// An error writing to w is reported and ends the stage, the remainder of in is drained in the
+// background.
+func EncodeCSVWithError[T any](size int, w io.Writer, in <-chan T) pipes.ChanPull[error] {
+	err := make(chan error, size)
+
+	go encodeCSVWithErrorWorker(w, in, err)
+
+	return err
+}
+
+func encodeCSVWithErrorWorker[T any](w io.Writer, in <-chan T, err chan<- error) {
+	defer close(err)
+
+	encodeCSVWorker(w, func(er error) { err <- er }, in)
+}
+
+// EncodeCSVWithErrorSink is the blocking equivalent of EncodeCSVWithError passing errors to sink.
+func EncodeCSVWithErrorSink[T any](w io.Writer, sink func(error), in <-chan T) {
+	encodeCSVWorker(w, sink, in)
+}
+
+func encodeCSVWorker[T any](w io.Writer, sink func(error), in <-chan T) {
+	fields, err := csvFieldsOf(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		sink(err)
+		go pipes.ChanPull[T](in).Drain()
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	cw := csv.NewWriter(buf)
+	record := make([]string, len(fields))
+	writeRecord := func() ([]byte, error) {
+		buf.Reset()
+		if err := cw.Write(record); err != nil {
+			return nil, err
+		}
+		cw.Flush()
+
+		return buf.Bytes(), cw.Error()
+	}
+
+	for i, f := range fields {
+		record[i] = f.name
+	}
+	header, _ := writeRecord()
+	if _, err := w.Write(header); err != nil {
+		sink(err)
+		go pipes.ChanPull[T](in).Drain()
+		return
+	}
+
+	marshal := func(t T) ([]byte, error) {
+		v := reflect.ValueOf(t)
+		for i, f := range fields {
+			// a field promoted through a nil embedded pointer is written as an empty cell
+			fv, err := v.FieldByIndexErr(f.index)
+			if err != nil {
+				record[i] = ""
+				continue
+			}
+
+			s, err := csvEncodeField(fv)
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", f.name, err)
+			}
+			record[i] = s
+		}
+
+		return writeRecord()
+	}
+
+	// the header occupies the first line
+	encodeWorker(w, 2, marshal, sink, in)
+}
+
+type csvField struct {
+	name  string
+	index []int
+}
+
+// csvFieldsOf returns the columns of struct type t in field order.
+func csvFieldsOf(t reflect.Type) ([]csvField, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("codec: csv requires a struct type, got %s", t)
+	}
+
+	var fields []csvField
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("csv"); ok {
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+
+		fields = append(fields, csvField{name: name, index: f.Index})
+	}
+
+	return fields, nil
+}
+
+// csvFieldIndex returns the index of the field for the named column, preferring an exact match, or
+// nil if there is no such field.
+func csvFieldIndex(fields []csvField, name string) []int {
+	var fold []int
+	for _, f := range fields {
+		if f.name == name {
+			return f.index
+		}
+		if fold == nil && strings.EqualFold(f.name, name) {
+			fold = f.index
+		}
+	}
+
+	return fold
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// csvFieldAlloc returns the field of v at index, allocating any nil embedded pointers on the way.
+func csvFieldAlloc(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported struct %s", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v, nil
+}
+
+func csvDecodeField(v reflect.Value, s string) error {
+	// pointer fields are left nil for an empty cell
+	if v.Kind() == reflect.Pointer {
+		if s == "" {
+			v.SetZero()
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		if u, ok := v.Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(s))
+		}
+		return csvDecodeField(v.Elem(), s)
+	}
+
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+func csvEncodeField(v reflect.Value) (string, error) {
+	// nil pointers and interfaces are written as an empty cell
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+		return "", nil
+	}
+
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+		return string(b), err
+	}
+
+	if v.Kind() == reflect.Pointer {
+		return csvEncodeField(v.Elem())
+	}
+
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+
+	default:
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
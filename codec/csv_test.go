@@ -0,0 +1,59 @@
+package codec
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/curlymon/pipes"
+)
+
+type CSVInner struct {
+	City string `csv:"city"`
+}
+
+type csvRow struct {
+	Name string  `csv:"name"`
+	Age  *int    `csv:"age"`
+	IP   *net.IP `csv:"ip"`
+	*CSVInner
+}
+
+func TestEncodeCSVWritesNilPointersAsEmptyCells(t *testing.T) {
+	var buf bytes.Buffer
+	age := 3
+	ip := net.IPv4(127, 0, 0, 1)
+
+	rows := []csvRow{
+		{Name: "a"},
+		{Name: "b", Age: &age, IP: &ip, CSVInner: &CSVInner{City: "x"}},
+	}
+	for err := range EncodeCSVWithError(0, &buf, pipes.FromSlice(0, rows)) {
+		t.Fatal(err)
+	}
+
+	want := "name,age,ip,city\na,,,\nb,3,127.0.0.1,x\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestDecodeCSVAllocatesPointers(t *testing.T) {
+	var errs []error
+	rows := pipes.ToSlice(DecodeCSVWithErrorSink[csvRow](0, func(err error) { errs = append(errs, err) },
+		strings.NewReader("name,age,ip,city\na,,,\nb,3,127.0.0.1,x\n")))
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+	if r := rows[0]; r.Age != nil || r.IP != nil {
+		t.Fatalf("got %+v, want nil pointers for empty cells", r)
+	}
+	if r := rows[1]; r.Age == nil || *r.Age != 3 || r.IP == nil || !r.IP.Equal(net.IPv4(127, 0, 0, 1)) || r.CSVInner == nil || r.City != "x" {
+		t.Fatalf("got %+v, want every field decoded", r)
+	}
+}
@@ -0,0 +1,94 @@
+package codec
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+
+	"github.com/curlymon/pipes"
+)
+
+// DecodeGobWithError decodes each value of a gob stream read from r as a T, closing the returned
+// channel once r is exhausted. A gob stream cannot be resynchronised after a failed decode so any
+// error is reported as a *RecordError and ends the stream.
+func DecodeGobWithError[T any](size int, r io.Reader) (pipes.ChanPull[T], pipes.ChanPull[error]) {
+	out, err := make(chan T, size), make(chan error, size)
+
+	go decodeGobWithErrorWorker(r, out, err)
+
+	return out, err
+}
+
+func decodeGobWithErrorWorker[T any](r io.Reader, out chan<- T, err chan<- error) {
+	defer func() { close(out); close(err) }()
+
+	decodeGobWorker(r, func(er error) { err <- er }, out)
+}
+
+// DecodeGobWithErrorSink behaves as DecodeGobWithError passing errors to sink.
+func DecodeGobWithErrorSink[T any](size int, sink func(error), r io.Reader) pipes.ChanPull[T] {
+	out := make(chan T, size)
+
+	go decodeGobWithErrorSinkWorker(r, sink, out)
+
+	return out
+}
+
+func decodeGobWithErrorSinkWorker[T any](r io.Reader, sink func(error), out chan<- T) {
+	defer close(out)
+
+	decodeGobWorker(r, sink, out)
+}
+
+func decodeGobWorker[T any](r io.Reader, sink func(error), out chan<- T) {
+	dec := gob.NewDecoder(r)
+	for record := 1; ; record++ {
+		var t T
+		if err := dec.Decode(&t); err != nil {
+			if !errors.Is(err, io.EOF) {
+				sink(&RecordError{Line: record, Err: err})
+			}
+			return
+		}
+
+		out <- t
+	}
+}
+
+// EncodeGobWithError writes each T read from in to w as a gob stream. As with decoding a gob stream
+// cannot recover from a failed encode, so any error is reported and ends the stage with the
+// remainder of in drained in the background.
+func EncodeGobWithError[T any](size int, w io.Writer, in <-chan T) pipes.ChanPull[error] {
+	err := make(chan error, size)
+
+	go encodeGobWithErrorWorker(w, in, err)
+
+	return err
+}
+
+func encodeGobWithErrorWorker[T any](w io.Writer, in <-chan T, err chan<- error) {
+	defer close(err)
+
+	encodeGobWorker(w, func(er error) { err <- er }, in)
+}
+
+// EncodeGobWithErrorSink is the blocking equivalent of EncodeGobWithError passing errors to sink.
+func EncodeGobWithErrorSink[T any](w io.Writer, sink func(error), in <-chan T) {
+	encodeGobWorker(w, sink, in)
+}
+
+func encodeGobWorker[T any](w io.Writer, sink func(error), in <-chan T) {
+	enc := gob.NewEncoder(w)
+	for record := 1; ; record++ {
+		t, ok := <-in
+		if !ok {
+			return
+		}
+
+		if err := enc.Encode(t); err != nil {
+			sink(&RecordError{Line: record, Err: err})
+			go pipes.ChanPull[T](in).Drain()
+			return
+		}
+	}
+}
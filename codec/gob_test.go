@@ -0,0 +1,48 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/curlymon/pipes"
+)
+
+func TestGobRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	want := []record{{"a", 1}, {"b", 2}, {"c", 3}}
+
+	for err := range EncodeGobWithError(0, &buf, pipes.FromSlice(0, want)) {
+		t.Fatal(err)
+	}
+
+	out, errs := DecodeGobWithError[record](len(want), &buf)
+	got := out.ToSlice()
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestDecodeGobEndsOnMalformedRecord(t *testing.T) {
+	var buf bytes.Buffer
+	for err := range EncodeGobWithError(0, &buf, pipes.FromSlice(0, []record{{"a", 1}, {"b", 2}})) {
+		t.Fatal(err)
+	}
+	// truncating the stream leaves the last record incomplete
+	buf.Truncate(buf.Len() - 2)
+
+	var errs []error
+	got := pipes.ToSlice(DecodeGobWithErrorSink[record](0, func(err error) { errs = append(errs, err) }, &buf))
+
+	if want := []record{{"a", 1}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	var re *RecordError
+	if len(errs) != 1 || !errors.As(errs[0], &re) || re.Line != 2 {
+		t.Fatalf("got %v, want a *RecordError for record 2", errs)
+	}
+}
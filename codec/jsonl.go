@@ -0,0 +1,133 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/curlymon/pipes"
+)
+
+// DecodeJSONLinesWithError decodes each line read from r as a JSON encoded T, closing the returned
+// channel once r is exhausted. Blank lines are skipped. Lines that fail to decode are reported as a
+// *RecordError and skipped, while an error reading r is reported and ends the stream.
+func DecodeJSONLinesWithError[T any](size int, r io.Reader) (pipes.ChanPull[T], pipes.ChanPull[error]) {
+	out, err := make(chan T, size), make(chan error, size)
+
+	go decodeJSONLinesWithErrorWorker(r, out, err)
+
+	return out, err
+}
+
+func decodeJSONLinesWithErrorWorker[T any](r io.Reader, out chan<- T, err chan<- error) {
+	defer func() { close(out); close(err) }()
+
+	decodeJSONLinesWorker(r, func(er error) { err <- er }, out)
+}
+
+// DecodeJSONLinesWithErrorSink behaves as DecodeJSONLinesWithError passing errors to sink.
+func DecodeJSONLinesWithErrorSink[T any](size int, sink func(error), r io.Reader) pipes.ChanPull[T] {
+	out := make(chan T, size)
+
+	go decodeJSONLinesWithErrorSinkWorker(r, sink, out)
+
+	return out
+}
+
+func decodeJSONLinesWithErrorSinkWorker[T any](r io.Reader, sink func(error), out chan<- T) {
+	defer close(out)
+
+	decodeJSONLinesWorker(r, sink, out)
+}
+
+func decodeJSONLinesWorker[T any](r io.Reader, sink func(error), out chan<- T) {
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		// ReadBytes rather than a bufio.Scanner so that records are not limited to a maximum size
+		b, err := br.ReadBytes('\n')
+		if b = bytes.TrimSpace(b); len(b) > 0 {
+			var t T
+			if er := json.Unmarshal(b, &t); er != nil {
+				sink(&RecordError{Line: line, Err: er})
+			} else {
+				out <- t
+			}
+		}
+
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				sink(err)
+			}
+			return
+		}
+	}
+}
+
+// EncodeJSONLinesWithError writes each T read from in to w as a line of JSON. Items that fail to
+// encode are reported as a *RecordError and skipped. An error writing to w is reported and ends the
+// stage, the remainder of in is drained in the background.
+func EncodeJSONLinesWithError[T any](size int, w io.Writer, in <-chan T) pipes.ChanPull[error] {
+	err := make(chan error, size)
+
+	go encodeJSONLinesWithErrorWorker(w, in, err)
+
+	return err
+}
+
+func encodeJSONLinesWithErrorWorker[T any](w io.Writer, in <-chan T, err chan<- error) {
+	defer close(err)
+
+	encodeJSONLinesWorker(w, func(er error) { err <- er }, in)
+}
+
+// EncodeJSONLinesWithErrorSink is the blocking equivalent of EncodeJSONLinesWithError passing errors to
+// sink.
+func EncodeJSONLinesWithErrorSink[T any](w io.Writer, sink func(error), in <-chan T) {
+	encodeJSONLinesWorker(w, sink, in)
+}
+
+func encodeJSONLinesWorker[T any](w io.Writer, sink func(error), in <-chan T) {
+	encodeWorker(w, 1, marshalJSONLine[T], sink, in)
+}
+
+func marshalJSONLine[T any](t T) ([]byte, error) {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, '\n'), nil
+}
+
+// encodeWorker writes each T read from in to w as marshalled by marshal, line being the line number
+// of the first record for error reporting. Items that fail to marshal
+// are reported as a *RecordError and skipped while an error writing to w ends the stage, draining
+// the remainder of in in the background. Writes are buffered and flushed once in is closed.
+func encodeWorker[T any](w io.Writer, line int, marshal func(T) ([]byte, error), sink func(error), in <-chan T) {
+	bw := bufio.NewWriter(w)
+
+	for ; ; line++ {
+		t, ok := <-in
+		if !ok {
+			break
+		}
+
+		b, err := marshal(t)
+		if err != nil {
+			sink(&RecordError{Line: line, Err: err})
+			continue
+		}
+
+		if _, err := bw.Write(b); err != nil {
+			sink(err)
+			go pipes.ChanPull[T](in).Drain()
+			return
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		sink(err)
+	}
+}
@@ -0,0 +1,69 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/curlymon/pipes"
+)
+
+type record struct {
+	Name  string
+	Count int
+}
+
+func TestJSONLinesRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	want := []record{{"a", 1}, {"b", 2}, {"c", 3}}
+
+	for err := range EncodeJSONLinesWithError(0, &buf, pipes.FromSlice(0, want)) {
+		t.Fatal(err)
+	}
+
+	out, errs := DecodeJSONLinesWithError[record](len(want), &buf)
+	got := out.ToSlice()
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestDecodeJSONLinesReportsMalformedLines(t *testing.T) {
+	in := "{\"Name\":\"a\",\"Count\":1}\n\n{\"Name\":\n{\"Name\":\"c\",\"Count\":3}\n"
+
+	var errs []error
+	got := pipes.ToSlice(DecodeJSONLinesWithErrorSink[record](0, func(err error) { errs = append(errs, err) },
+		strings.NewReader(in)))
+
+	if want := []record{{"a", 1}, {"c", 3}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("got %v, want 1 error", errs)
+	}
+	var re *RecordError
+	if !errors.As(errs[0], &re) || re.Line != 3 {
+		t.Fatalf("got %v, want a *RecordError for line 3", errs[0])
+	}
+}
+
+func TestEncodeJSONLinesReportsUnencodableItems(t *testing.T) {
+	var buf bytes.Buffer
+
+	var errs []error
+	EncodeJSONLinesWithErrorSink(&buf, func(err error) { errs = append(errs, err) },
+		pipes.FromSlice(0, []any{1, func() {}, 3}))
+
+	if got, want := buf.String(), "1\n3\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	var re *RecordError
+	if len(errs) != 1 || !errors.As(errs[0], &re) || re.Line != 2 {
+		t.Fatalf("got %v, want a *RecordError for line 2", errs)
+	}
+}
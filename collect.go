@@ -0,0 +1,66 @@
+package pipes
+
+// ToSlice is a blocking operation that collects each T read from in into a slice, returning it once
+// in is closed.
+func ToSlice[T any](in <-chan T) []T {
+	var s []T
+	for t := range in {
+		s = append(s, t)
+	}
+
+	return s
+}
+
+// ToMap is a blocking operation that collects each T read from in into a map under the key returned
+// by key, returning it once in is closed. When two items share a key resolve is called with the
+// existing and new item to decide which is kept, see KeepFirst and KeepLast. A nil resolve keeps the
+// last item seen.
+func ToMap[T any, K comparable](key func(T) K, resolve func(old, new T) T, in <-chan T) map[K]T {
+	if resolve == nil {
+		resolve = KeepLast[T]
+	}
+
+	m := make(map[K]T)
+	for t := range in {
+		k := key(t)
+		if old, exists := m[k]; exists {
+			t = resolve(old, t)
+		}
+		m[k] = t
+	}
+
+	return m
+}
+
+// KeepFirst is a ToMap conflict resolver keeping the first item seen for a key.
+func KeepFirst[T any](old, _ T) T {
+	return old
+}
+
+// KeepLast is a ToMap conflict resolver keeping the last item seen for a key.
+func KeepLast[T any](_, new T) T {
+	return new
+}
+
+// GroupToMap is a blocking operation that groups each T read from in by the key returned by key,
+// returning the groups once in is closed. Items within a group keep the order they were read in.
+func GroupToMap[T any, K comparable](key func(T) K, in <-chan T) map[K][]T {
+	m := make(map[K][]T)
+	for t := range in {
+		k := key(t)
+		m[k] = append(m[k], t)
+	}
+
+	return m
+}
+
+// Count is a blocking operation that counts the items read from in, returning the count once in is
+// closed.
+func Count[T any](in <-chan T) int {
+	n := 0
+	for range in {
+		n++
+	}
+
+	return n
+}
@@ -0,0 +1,26 @@
+package pipes
+
+// Signed is a constraint permitting any signed integer type.
+type Signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+// Unsigned is a constraint permitting any unsigned integer type.
+type Unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Integer is a constraint permitting any integer type.
+type Integer interface {
+	Signed | Unsigned
+}
+
+// Float is a constraint permitting any floating point type.
+type Float interface {
+	~float32 | ~float64
+}
+
+// Number is a constraint permitting any integer or floating point type.
+type Number interface {
+	Integer | Float
+}
@@ -0,0 +1,143 @@
+// Package dlq provides dead-letter queues capturing items that failed processing so they can be
+// inspected and replayed into a pipeline later.
+package dlq
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/curlymon/pipes"
+	"github.com/curlymon/pipes/codec"
+)
+
+// Letter is an item that failed processing along with the details of its failure.
+type Letter[T any] struct {
+	Item    T         `json:"item"`
+	Err     string    `json:"error"`
+	Stage   string    `json:"stage"`
+	Time    time.Time `json:"time"`
+	Attempt int       `json:"attempt"`
+}
+
+// Queue stores dead letters. Implementations must be safe for concurrent use.
+type Queue[T any] interface {
+	Put(Letter[T]) error
+}
+
+// Func adapts a function to a Queue.
+type Func[T any] func(Letter[T]) error
+
+func (f Func[T]) Put(l Letter[T]) error {
+	return f(l)
+}
+
+// Chan is an in-memory Queue exposing its letters on a channel.
+type Chan[T any] struct {
+	ch pipes.Chan[Letter[T]]
+}
+
+// NewChan returns a Chan buffering up to size letters. Put blocks while the buffer is full.
+func NewChan[T any](size int) *Chan[T] {
+	return &Chan[T]{ch: pipes.New[Letter[T]](size)}
+}
+
+func (c *Chan[T]) Put(l Letter[T]) error {
+	c.ch.Push(l)
+	return nil
+}
+
+// Letters returns the channel letters are pushed onto.
+func (c *Chan[T]) Letters() pipes.ChanPull[Letter[T]] {
+	return c.ch.ChanPull()
+}
+
+// Close closes the channel returned by Letters, no more letters may be put afterwards.
+func (c *Chan[T]) Close() {
+	c.ch.Close()
+}
+
+// JSONLines is a Queue writing letters to an io.Writer, such as a file opened for appending, as
+// JSON Lines. Replay reads them back.
+type JSONLines[T any] struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLines returns a JSONLines writing to w.
+func NewJSONLines[T any](w io.Writer) *JSONLines[T] {
+	return &JSONLines[T]{w: w}
+}
+
+func (j *JSONLines[T]) Put(l Letter[T]) error {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	_, err = j.w.Write(append(b, '\n'))
+
+	return err
+}
+
+// Capture wraps mp so that it is attempted up to attempts times, after which the item is put on q
+// as a Letter from the given stage. The last error is still returned, joined with any error from q,
+// so the returned function can be passed to pipes.MapWithError or its sink and async counterparts.
+func Capture[T any, N any](q Queue[T], stage string, attempts int, mp func(T) (N, error)) func(T) (N, error) {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	return func(t T) (N, error) {
+		var (
+			n   N
+			err error
+		)
+		for attempt := 1; attempt <= attempts; attempt++ {
+			if n, err = mp(t); err == nil {
+				return n, nil
+			}
+
+			if attempt == attempts {
+				if er := q.Put(letter(t, err, stage, attempt)); er != nil {
+					err = errors.Join(err, er)
+				}
+			}
+		}
+
+		return n, err
+	}
+}
+
+// CaptureSink wraps sink so that it is attempted up to attempts times, after which the item is put
+// on q as a Letter from the given stage. The last error is still returned, joined with any error
+// from q, so the returned function can be passed to pipes.SinkWithError or its counterparts.
+func CaptureSink[T any](q Queue[T], stage string, attempts int, sink func(T) error) func(T) error {
+	mp := Capture(q, stage, attempts, func(t T) (struct{}, error) { return struct{}{}, sink(t) })
+	return func(t T) error {
+		_, err := mp(t)
+		return err
+	}
+}
+
+func letter[T any](t T, err error, stage string, attempt int) Letter[T] {
+	return Letter[T]{Item: t, Err: err.Error(), Stage: stage, Time: time.Now(), Attempt: attempt}
+}
+
+// Items pushes the item of each Letter read from in, allowing the contents of a Chan to be replayed
+// into a pipeline.
+func Items[T any](size int, in <-chan Letter[T]) pipes.ChanPull[T] {
+	return pipes.Map(size, func(l Letter[T]) T { return l.Item }, in)
+}
+
+// Replay pushes the item of each Letter read from r as written by JSONLines, allowing failed items
+// to be reprocessed. Letters that fail to decode are reported as a *codec.RecordError and skipped.
+func Replay[T any](size int, r io.Reader) (pipes.ChanPull[T], pipes.ChanPull[error]) {
+	letters, err := codec.DecodeJSONLinesWithError[Letter[T]](size, r)
+	return Items(size, letters), err
+}
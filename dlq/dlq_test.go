@@ -0,0 +1,106 @@
+package dlq
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/curlymon/pipes"
+)
+
+var errFailed = errors.New("failed")
+
+func TestCaptureRetriesThenPuts(t *testing.T) {
+	q := NewChan[int](10)
+
+	calls := map[int]int{}
+	mp := Capture(q, "double", 3, func(i int) (int, error) {
+		calls[i]++
+		// 1 succeeds on the second attempt, 2 always fails
+		if i == 2 || calls[i] < 2 {
+			return 0, errFailed
+		}
+		return i * 2, nil
+	})
+
+	before := time.Now()
+	if n, err := mp(1); err != nil || n != 2 {
+		t.Fatalf("got %d %v, want 2 nil", n, err)
+	}
+	if _, err := mp(2); !errors.Is(err, errFailed) {
+		t.Fatalf("got %v, want %v", err, errFailed)
+	}
+	q.Close()
+
+	if calls[1] != 2 || calls[2] != 3 {
+		t.Fatalf("got calls %v, want 1 twice and 2 three times", calls)
+	}
+
+	letters := pipes.ToSlice(q.Letters())
+	if len(letters) != 1 {
+		t.Fatalf("got %d letters, want 1", len(letters))
+	}
+
+	l := letters[0]
+	if l.Item != 2 || l.Err != errFailed.Error() || l.Stage != "double" || l.Attempt != 3 || l.Time.Before(before) {
+		t.Fatalf("got %+v, want item 2 failed in double on attempt 3", l)
+	}
+}
+
+func TestCaptureJoinsPutError(t *testing.T) {
+	errPut := errors.New("put")
+	q := Func[int](func(Letter[int]) error { return errPut })
+
+	_, err := Capture(q, "stage", 1, func(int) (int, error) { return 0, errFailed })(1)
+	if !errors.Is(err, errFailed) || !errors.Is(err, errPut) {
+		t.Fatalf("got %v, want both %v and %v", err, errFailed, errPut)
+	}
+}
+
+func TestCaptureSink(t *testing.T) {
+	q := NewChan[int](10)
+
+	sink := CaptureSink(q, "sink", 1, func(i int) error {
+		if i%2 != 0 {
+			return errFailed
+		}
+		return nil
+	})
+
+	errs := pipes.SinkWithError(0, sink, pipes.FromSlice(0, []int{1, 2, 3, 4}))
+	if n := errs.Count(); n != 2 {
+		t.Fatalf("got %d errors, want 2", n)
+	}
+	q.Close()
+
+	if got := pipes.ToSlice(Items(0, q.Letters())); !slices.Equal(got, []int{1, 3}) {
+		t.Fatalf("got %v, want [1 3]", got)
+	}
+}
+
+func TestJSONLinesReplay(t *testing.T) {
+	buf := &bytes.Buffer{}
+	q := NewJSONLines[int](buf)
+
+	mp := Capture(q, "stage", 1, func(int) (int, error) { return 0, errFailed })
+	for _, i := range []int{1, 2, 3} {
+		mp(i)
+	}
+
+	// a damaged line is reported and skipped
+	r := strings.NewReader(buf.String() + "{not json\n")
+
+	out, errs := Replay[int](0, r)
+	count := make(chan int)
+	go func() { count <- errs.Count() }()
+
+	if got := pipes.ToSlice(out); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("got %v, want [1 2 3]", got)
+	}
+	if n := <-count; n != 1 {
+		t.Fatalf("got %d errors, want 1", n)
+	}
+}
@@ -0,0 +1,716 @@
+// Package durable provides a persistent queue that survives process restarts.
+//
+// Every item pushed onto a Queue is appended to a write-ahead log on local disk before it becomes
+// available to Pull. Items remain in the log until acknowledged, so any item pulled but not yet
+// acknowledged when the process stops is redelivered once the Queue is reopened. The log is split
+// into segments that are deleted once every item they hold has been acknowledged. Only a window of
+// the items waiting to be pulled is held in memory, the rest are read back from the log as needed.
+package durable
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/curlymon/pipes"
+	"github.com/curlymon/pipes/ack"
+	"github.com/curlymon/pipes/codec"
+)
+
+var (
+	// ErrClosed is returned when pushing onto a closed Queue.
+	ErrClosed = errors.New("durable: queue closed")
+	// ErrStopped is returned by any operation on a stopped Queue.
+	ErrStopped = errors.New("durable: queue stopped")
+	// ErrUnknownSeq is returned when acknowledging an item that is not in flight.
+	ErrUnknownSeq = errors.New("durable: unknown sequence")
+	// ErrFailed is returned by any write to a Queue whose log could not be repaired after a failed
+	// write, see Queue.Err.
+	ErrFailed = errors.New("durable: queue failed")
+)
+
+// SyncPolicy decides when the log is flushed to stable storage with fsync.
+type SyncPolicy int
+
+const (
+	// SyncAlways fsyncs after every push and acknowledgement.
+	SyncAlways SyncPolicy = iota
+	// SyncInterval fsyncs every Options.SyncInterval, bounding the writes lost on power failure.
+	SyncInterval
+	// SyncNever leaves flushing to the operating system.
+	SyncNever
+)
+
+// Options configures a Queue.
+type Options struct {
+	// Codec encodes items in the log. Defaults to codec.Gob.
+	Codec codec.Codec
+	// Sync is the fsync policy. Defaults to SyncAlways.
+	Sync SyncPolicy
+	// SyncInterval is the fsync interval of SyncInterval. Defaults to 100ms.
+	SyncInterval time.Duration
+	// SegmentSize is the size in bytes after which a new log segment is started. Defaults to 64MiB.
+	SegmentSize int64
+	// ReadAhead is the number of items waiting to be pulled that are held in memory, the rest are
+	// read back from the log once those have been pulled. Defaults to 1024.
+	ReadAhead int
+}
+
+// Entry is an item pulled from a Queue along with the sequence it is acknowledged by.
+type Entry[T any] struct {
+	Seq   uint64
+	Value T
+}
+
+const segmentExt = ".wal"
+
+type segment struct {
+	index uint64
+	path  string
+	size  int64
+	live  int // pushed items not yet acknowledged
+}
+
+// position is an offset into the log, the zero position is the start of the log.
+type position struct {
+	index  uint64
+	offset int64
+}
+
+// logFile is the head segment being appended to, an *os.File outside of tests.
+type logFile interface {
+	io.WriteCloser
+	Sync() error
+	Truncate(size int64) error
+}
+
+// Queue is a persistent FIFO queue of T backed by a segmented write-ahead log. It is safe for
+// concurrent use.
+type Queue[T any] struct {
+	dir  string
+	opts Options
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	segments []*segment // oldest first, the last is the head being appended to
+	head     logFile
+	seq      uint64
+	ready    []Entry[T] // read from the log up to cursor, ordered by sequence
+	cursor   position
+	inflight map[uint64]T
+	segOf    map[uint64]*segment // every item not yet acknowledged
+	dirty    bool
+	closed   bool
+	stopped  bool
+	err      error
+	buf      []byte
+	done     chan struct{}
+}
+
+// Open opens the Queue stored in dir, creating it if needed. Any items left unacknowledged by a
+// previous process are recovered and delivered first in the order they were pushed. A record torn
+// by a crash at the end of the log is discarded.
+func Open[T any](dir string, opts Options) (*Queue[T], error) {
+	if opts.Codec == nil {
+		opts.Codec = codec.Gob
+	}
+	if opts.SyncInterval <= 0 {
+		opts.SyncInterval = 100 * time.Millisecond
+	}
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = 64 << 20
+	}
+	if opts.ReadAhead <= 0 {
+		opts.ReadAhead = 1024
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	q := &Queue[T]{
+		dir:      dir,
+		opts:     opts,
+		inflight: make(map[uint64]T),
+		segOf:    make(map[uint64]*segment),
+		done:     make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.mu)
+
+	if err := q.recover(); err != nil {
+		return nil, err
+	}
+
+	if opts.Sync == SyncInterval {
+		go q.syncWorker()
+	}
+
+	return q, nil
+}
+
+// recover replays every segment in dir and starts a new head segment. Items are left in the log to
+// be read by fill, starting from the zero cursor.
+func (q *Queue[T]) recover() error {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+
+		index, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		q.segments = append(q.segments, &segment{index: index, path: filepath.Join(q.dir, name)})
+	}
+	sort.Slice(q.segments, func(i, j int) bool { return q.segments[i].index < q.segments[j].index })
+
+	for i, seg := range q.segments {
+		if err := q.replay(seg, i == len(q.segments)-1); err != nil {
+			return err
+		}
+	}
+
+	index := uint64(1)
+	if n := len(q.segments); n > 0 {
+		index = q.segments[n-1].index + 1
+	}
+
+	if err := q.rotate(index); err != nil {
+		return err
+	}
+
+	return q.compact()
+}
+
+// replay reads the records of seg tracking the items not yet acknowledged. A torn record at the end
+// of the last segment is truncated, anywhere else it is reported as corruption.
+func (q *Queue[T]) replay(seg *segment, last bool) error {
+	f, err := os.OpenFile(seg.path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var offset int64
+	for {
+		kind, seq, payload, err := readRecord(r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if !last || (!errors.Is(err, errCorrupt) && !errors.Is(err, io.ErrUnexpectedEOF)) {
+				return fmt.Errorf("durable: segment %s at offset %d: %w", seg.path, offset, err)
+			}
+			if err := f.Truncate(offset); err != nil {
+				return err
+			}
+			break
+		}
+		offset += int64(recordHeader + recordBody + len(payload))
+
+		if seq > q.seq {
+			q.seq = seq
+		}
+
+		switch kind {
+		case recordPush:
+			// decoded only to report a damaged log on open rather than when pulled
+			if _, err := q.decode(payload); err != nil {
+				return fmt.Errorf("durable: segment %s seq %d: %w", seg.path, seq, err)
+			}
+			q.segOf[seq] = seg
+			seg.live++
+
+		case recordAck:
+			// acks for items in segments already deleted are expected and ignored
+			if s, ok := q.segOf[seq]; ok {
+				delete(q.segOf, seq)
+				s.live--
+			}
+		}
+	}
+	seg.size = offset
+
+	return nil
+}
+
+func (q *Queue[T]) decode(payload []byte) (T, error) {
+	var t T
+	err := q.opts.Codec.NewDecoder(bytes.NewReader(payload)).Decode(&t)
+	return t, err
+}
+
+func (q *Queue[T]) encode(t T) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	err := q.opts.Codec.NewEncoder(buf).Encode(t)
+	return buf.Bytes(), err
+}
+
+// rotate starts a new head segment with the given index. The current head is left in place if the
+// new one can't be started. The caller must hold mu.
+func (q *Queue[T]) rotate(index uint64) error {
+	path := filepath.Join(q.dir, fmt.Sprintf("%020d%s", index, segmentExt))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if q.head != nil {
+		if err := q.head.Sync(); err != nil {
+			f.Close()
+			os.Remove(path)
+			return err
+		}
+		// every record is synced, a failed close loses nothing
+		q.head.Close()
+	}
+
+	q.head = f
+	q.segments = append(q.segments, &segment{index: index, path: path})
+
+	return nil
+}
+
+// compact deletes the oldest segments while every item they hold has been acknowledged. Only a
+// prefix of the log is ever deleted so that no surviving segment depends on the acks recorded in a
+// deleted one. The caller must hold mu.
+func (q *Queue[T]) compact() error {
+	for len(q.segments) > 1 && q.segments[0].live == 0 {
+		if err := os.Remove(q.segments[0].path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		q.segments = q.segments[1:]
+	}
+
+	return nil
+}
+
+// write appends a record to the head segment applying the sync policy, first rotating the head if
+// full, and returns the segment written to. A record that fails to be written or synced is
+// truncated from the log so that it is not recovered nor followed by later records. The caller must
+// hold mu.
+func (q *Queue[T]) write(kind recordKind, seq uint64, payload []byte) (*segment, error) {
+	if q.err != nil {
+		return nil, ErrFailed
+	}
+
+	head := q.segments[len(q.segments)-1]
+	if head.size >= q.opts.SegmentSize {
+		if err := q.rotate(head.index + 1); err != nil {
+			return nil, err
+		}
+		head = q.segments[len(q.segments)-1]
+	}
+
+	q.buf = appendRecord(q.buf[:0], kind, seq, payload)
+	_, err := q.head.Write(q.buf)
+	if err == nil && q.opts.Sync == SyncAlways {
+		err = q.head.Sync()
+	}
+	if err != nil {
+		return nil, q.discard(head, err)
+	}
+
+	head.size += int64(len(q.buf))
+	if q.opts.Sync == SyncInterval {
+		q.dirty = true
+	}
+
+	return head, nil
+}
+
+// discard truncates head back to the end of its last complete record after a write failed with err.
+// If the log can't be repaired the Queue fails, leaving the damaged record for Open to truncate.
+// The caller must hold mu.
+func (q *Queue[T]) discard(head *segment, err error) error {
+	terr := q.head.Truncate(head.size)
+	if terr == nil && q.opts.Sync == SyncAlways {
+		terr = q.head.Sync()
+	}
+	if terr != nil {
+		q.err = fmt.Errorf("durable: truncating segment %s: %w", head.path, errors.Join(err, terr))
+		q.cond.Broadcast()
+		return q.err
+	}
+
+	return err
+}
+
+// caughtUp reports whether the cursor is at the end of the log, every item waiting to be pulled
+// being in ready. The caller must hold mu.
+func (q *Queue[T]) caughtUp() bool {
+	for _, seg := range q.segments {
+		switch {
+		case seg.index < q.cursor.index:
+		case seg.index == q.cursor.index:
+			if q.cursor.offset < seg.size {
+				return false
+			}
+		case seg.size > 0:
+			return false
+		}
+	}
+
+	return true
+}
+
+// fill reads the items after the cursor back from the log into ready until ReadAhead items are
+// held or the end of the log is reached. The caller must hold mu.
+func (q *Queue[T]) fill() error {
+	for _, seg := range q.segments {
+		if len(q.ready) >= q.opts.ReadAhead {
+			return nil
+		}
+
+		switch {
+		case seg.index < q.cursor.index:
+			continue
+		case seg.index > q.cursor.index:
+			// the cursor's segment is done with, it may have been deleted
+			q.cursor = position{index: seg.index}
+		}
+
+		if err := q.fillFrom(seg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fillFrom reads the items after the cursor in seg into ready. The caller must hold mu.
+func (q *Queue[T]) fillFrom(seg *segment) error {
+	if q.cursor.offset >= seg.size {
+		return nil
+	}
+
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(io.NewSectionReader(f, q.cursor.offset, seg.size-q.cursor.offset))
+	for len(q.ready) < q.opts.ReadAhead && q.cursor.offset < seg.size {
+		kind, seq, payload, err := readRecord(r)
+		if err != nil {
+			return fmt.Errorf("durable: segment %s at offset %d: %w", seg.path, q.cursor.offset, err)
+		}
+		q.cursor.offset += int64(recordHeader + recordBody + len(payload))
+
+		// acks and acknowledged items are skipped
+		if _, ok := q.segOf[seq]; kind != recordPush || !ok {
+			continue
+		}
+
+		t, err := q.decode(payload)
+		if err != nil {
+			return fmt.Errorf("durable: segment %s seq %d: %w", seg.path, seq, err)
+		}
+		q.ready = append(q.ready, Entry[T]{Seq: seq, Value: t})
+	}
+
+	return nil
+}
+
+// Push appends t to the log and makes it available to Pull.
+func (q *Queue[T]) Push(t T) error {
+	payload, err := q.encode(t)
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	switch {
+	case q.stopped:
+		return ErrStopped
+	case q.closed:
+		return ErrClosed
+	}
+
+	// t is only kept in memory if every item before it is, otherwise fill reads it from the log
+	keep := q.caughtUp() && len(q.ready) < q.opts.ReadAhead
+
+	seq := q.seq + 1
+	seg, err := q.write(recordPush, seq, payload)
+	if err != nil {
+		return err
+	}
+
+	q.seq = seq
+	q.segOf[seq] = seg
+	seg.live++
+	if keep {
+		q.ready = append(q.ready, Entry[T]{Seq: seq, Value: t})
+		q.cursor = position{index: seg.index, offset: seg.size}
+	}
+	q.cond.Signal()
+
+	return nil
+}
+
+// Pull is a blocking operation that pulls the next Entry. This blocks while no Entry is available,
+// returning false once the Queue is closed and empty, stopped, or failed. The Entry must be
+// acknowledged with Ack once processed or it is redelivered when the Queue is next opened.
+func (q *Queue[T]) Pull() (Entry[T], bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		if e, ok := q.pull(); ok || q.closed || q.stopped || q.err != nil {
+			return e, ok
+		}
+		q.cond.Wait()
+	}
+}
+
+// TryPull is a non-blocking operation that attempts to pull the next Entry, returning false if none
+// is available.
+func (q *Queue[T]) TryPull() (Entry[T], bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.pull()
+}
+
+// pull pops the next ready Entry marking it in flight, reading more from the log once ready is
+// empty. A failure reading the log fails the Queue. The caller must hold mu.
+func (q *Queue[T]) pull() (Entry[T], bool) {
+	if q.stopped || q.err != nil {
+		return Entry[T]{}, false
+	}
+
+	if len(q.ready) == 0 {
+		if err := q.fill(); err != nil {
+			q.err = err
+			q.cond.Broadcast()
+			return Entry[T]{}, false
+		}
+		if len(q.ready) == 0 {
+			return Entry[T]{}, false
+		}
+	}
+
+	e := q.ready[0]
+	q.ready[0] = Entry[T]{}
+	q.ready = q.ready[1:]
+	q.inflight[e.Seq] = e.Value
+
+	return e, true
+}
+
+// Ack acknowledges the Entry pulled with the given sequence as processed, it will not be delivered
+// again.
+func (q *Queue[T]) Ack(seq uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.stopped {
+		return ErrStopped
+	}
+
+	if _, ok := q.inflight[seq]; !ok {
+		return ErrUnknownSeq
+	}
+
+	caughtUp := q.caughtUp()
+	seg, err := q.write(recordAck, seq, nil)
+	if err != nil {
+		return err
+	}
+	if caughtUp {
+		q.cursor = position{index: seg.index, offset: seg.size}
+	}
+
+	delete(q.inflight, seq)
+	q.segOf[seq].live--
+	delete(q.segOf, seq)
+
+	return q.compact()
+}
+
+// Nack returns the Entry pulled with the given sequence to the Queue to be pulled again ahead of
+// any Entry pushed after it.
+func (q *Queue[T]) Nack(seq uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.stopped {
+		return ErrStopped
+	}
+
+	t, ok := q.inflight[seq]
+	if !ok {
+		return ErrUnknownSeq
+	}
+
+	// keep ready ordered by sequence so that items nacked together are redelivered in order
+	delete(q.inflight, seq)
+	i := sort.Search(len(q.ready), func(i int) bool { return q.ready[i].Seq > seq })
+	q.ready = append(q.ready, Entry[T]{})
+	copy(q.ready[i+1:], q.ready[i:])
+	q.ready[i] = Entry[T]{Seq: seq, Value: t}
+	q.cond.Signal()
+
+	return nil
+}
+
+// Len returns the number of items waiting to be pulled.
+func (q *Queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.segOf) - len(q.inflight)
+}
+
+// Err returns the error that failed the Queue, if any. A Queue fails when its log can't be read,
+// can't be synced by SyncInterval, or can't be repaired after a failed write, after which writes
+// return ErrFailed and Pull returns false. Reopening the Queue recovers the log.
+func (q *Queue[T]) Err() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.err
+}
+
+// Close stops the Queue accepting pushes. As with closing a channel, items already pushed may still
+// be pulled after which Pull returns false. Acknowledgements are still accepted until Stop.
+func (q *Queue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// Sync flushes the log to stable storage.
+func (q *Queue[T]) Sync() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.stopped {
+		return ErrStopped
+	}
+
+	q.dirty = false
+
+	return q.head.Sync()
+}
+
+// Stop syncs and closes the log. Items not yet acknowledged remain in the log to be recovered when
+// the Queue is next opened.
+func (q *Queue[T]) Stop() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.stopped {
+		return nil
+	}
+
+	q.stopped = true
+	close(q.done)
+	q.cond.Broadcast()
+
+	if err := q.head.Sync(); err != nil {
+		q.head.Close()
+		return err
+	}
+
+	return q.head.Close()
+}
+
+func (q *Queue[T]) syncWorker() {
+	ticker := time.NewTicker(q.opts.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.done:
+			return
+
+		case <-ticker.C:
+			q.mu.Lock()
+			if q.dirty && !q.stopped && q.err == nil {
+				q.dirty = false
+				if err := q.head.Sync(); err != nil {
+					// records written since the last sync may be lost, fail rather than build on them
+					head := q.segments[len(q.segments)-1]
+					q.err = fmt.Errorf("durable: syncing segment %s: %w", head.path, err)
+					q.cond.Broadcast()
+				}
+			}
+			q.mu.Unlock()
+		}
+	}
+}
+
+// ChanPull pushes each Entry pulled from q onto the returned channel, acknowledging it once the
+// channel has accepted it. The channel is closed once q is closed and empty, or stopped. Items are
+// only redelivered after a crash if it happens before they are handed to the channel, use an
+// unbuffered channel to keep that window to a minimum.
+func (q *Queue[T]) ChanPull(size int) pipes.ChanPull[T] {
+	out := make(chan T, size)
+
+	go chanPullWorker(q, out)
+
+	return out
+}
+
+func chanPullWorker[T any](q *Queue[T], out chan<- T) {
+	defer close(out)
+
+	for {
+		e, ok := q.Pull()
+		if !ok {
+			return
+		}
+
+		out <- e.Value
+		q.Ack(e.Seq)
+	}
+}
+
+// Messages pushes each Entry pulled from q onto the returned channel as an ack.Message. Acking the
+// Message acknowledges the Entry while nacking it returns the Entry to q to be pulled again. The
+// channel is closed once q is closed and empty, or stopped, an Entry nacked after that remains in
+// the log to be recovered when q is next opened.
+func (q *Queue[T]) Messages(size int) pipes.ChanPull[ack.Message[T]] {
+	out := make(chan ack.Message[T], size)
+
+	go messagesWorker(q, out)
+
+	return out
+}
+
+func messagesWorker[T any](q *Queue[T], out chan<- ack.Message[T]) {
+	defer close(out)
+
+	for {
+		e, ok := q.Pull()
+		if !ok {
+			return
+		}
+
+		seq := e.Seq
+		out <- ack.New(e.Value, func() { q.Ack(seq) }, func(error) { q.Nack(seq) })
+	}
+}
@@ -0,0 +1,319 @@
+package durable
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func open(t *testing.T, dir string, opts Options) *Queue[int] {
+	t.Helper()
+
+	q, err := Open[int](dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return q
+}
+
+func push(t *testing.T, q *Queue[int], items ...int) {
+	t.Helper()
+
+	for _, i := range items {
+		if err := q.Push(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// pullAll pulls and acknowledges every item waiting in q.
+func pullAll(t *testing.T, q *Queue[int]) []int {
+	t.Helper()
+
+	var got []int
+	for {
+		e, ok := q.TryPull()
+		if !ok {
+			return got
+		}
+		got = append(got, e.Value)
+
+		if err := q.Ack(e.Seq); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRecoverRedeliversUnacknowledged(t *testing.T) {
+	dir := t.TempDir()
+
+	q := open(t, dir, Options{})
+	push(t, q, 1, 2, 3, 4, 5)
+
+	first, _ := q.Pull()
+	if err := q.Ack(first.Seq); err != nil {
+		t.Fatal(err)
+	}
+	// pulled but never acknowledged
+	q.Pull()
+
+	if err := q.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	q = open(t, dir, Options{})
+	defer q.Stop()
+
+	if got := q.Len(); got != 4 {
+		t.Fatalf("got Len %d, want 4", got)
+	}
+	if got := pullAll(t, q); !slices.Equal(got, []int{2, 3, 4, 5}) {
+		t.Fatalf("got %v, want [2 3 4 5]", got)
+	}
+}
+
+func TestReadAheadBoundsMemory(t *testing.T) {
+	dir := t.TempDir()
+
+	// small segments so the backlog spans many, some deleted while read back
+	opts := Options{SegmentSize: 64, ReadAhead: 3, Sync: SyncNever}
+	q := open(t, dir, opts)
+
+	var want []int
+	for i := range 50 {
+		want = append(want, i)
+	}
+	push(t, q, want...)
+
+	var got []int
+	for len(got) < 25 {
+		if len(q.ready) > opts.ReadAhead {
+			t.Fatalf("holding %d items in memory, want at most %d", len(q.ready), opts.ReadAhead)
+		}
+
+		e, _ := q.Pull()
+		got = append(got, e.Value)
+		q.Ack(e.Seq)
+	}
+
+	if err := q.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	q = open(t, dir, opts)
+	defer q.Stop()
+
+	push(t, q, 50, 51)
+	want = append(want, 50, 51)
+	for {
+		if len(q.ready) > opts.ReadAhead {
+			t.Fatalf("holding %d items in memory, want at most %d", len(q.ready), opts.ReadAhead)
+		}
+
+		e, ok := q.TryPull()
+		if !ok {
+			break
+		}
+		got = append(got, e.Value)
+		q.Ack(e.Seq)
+	}
+
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestNackIsPulledAgainInOrder(t *testing.T) {
+	q := open(t, t.TempDir(), Options{ReadAhead: 2})
+	defer q.Stop()
+
+	push(t, q, 1, 2, 3, 4)
+
+	a, _ := q.Pull()
+	b, _ := q.Pull()
+	q.Nack(b.Seq)
+	q.Nack(a.Seq)
+
+	if got := pullAll(t, q); !slices.Equal(got, []int{1, 2, 3, 4}) {
+		t.Fatalf("got %v, want [1 2 3 4]", got)
+	}
+}
+
+func TestOpenTruncatesTornTail(t *testing.T) {
+	dir := t.TempDir()
+
+	q := open(t, dir, Options{})
+	push(t, q, 1, 2, 3)
+	path := q.segments[len(q.segments)-1].path
+	if err := q.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a crash part way through appending a record
+	torn := appendRecord(nil, recordPush, 4, []byte("payload"))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write(torn[:len(torn)/2])
+	f.Close()
+
+	q = open(t, dir, Options{})
+	defer q.Stop()
+
+	if after, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	} else if after.Size() != info.Size() {
+		t.Fatalf("got segment size %d, want truncated to %d", after.Size(), info.Size())
+	}
+
+	if got := pullAll(t, q); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("got %v, want [1 2 3]", got)
+	}
+}
+
+func TestOpenReportsCorruptionBeforeTheTail(t *testing.T) {
+	dir := t.TempDir()
+
+	q := open(t, dir, Options{SegmentSize: 1})
+	push(t, q, 1, 2)
+	first := q.segments[0].path
+	q.Stop()
+
+	if err := os.Truncate(first, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open[int](dir, Options{}); err == nil {
+		t.Fatal("want an error for a damaged segment that is not the last")
+	}
+}
+
+// faultyFile fails every write after writing only half of it.
+type faultyFile struct {
+	*os.File
+	truncateErr error
+}
+
+var errFaulty = errors.New("faulty write")
+
+func (f *faultyFile) Write(p []byte) (int, error) {
+	n, _ := f.File.Write(p[:len(p)/2])
+	return n, errFaulty
+}
+
+func (f *faultyFile) Truncate(size int64) error {
+	if f.truncateErr != nil {
+		return f.truncateErr
+	}
+	return f.File.Truncate(size)
+}
+
+func TestFailedWriteIsTruncated(t *testing.T) {
+	dir := t.TempDir()
+
+	q := open(t, dir, Options{})
+	push(t, q, 1)
+
+	head := q.head
+	q.head = &faultyFile{File: head.(*os.File)}
+	if err := q.Push(2); !errors.Is(err, errFaulty) {
+		t.Fatalf("got %v, want %v", err, errFaulty)
+	}
+	q.head = head
+
+	push(t, q, 3)
+	if err := q.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	q = open(t, dir, Options{})
+	defer q.Stop()
+
+	if got := pullAll(t, q); !slices.Equal(got, []int{1, 3}) {
+		t.Fatalf("got %v, want [1 3]", got)
+	}
+}
+
+func TestFailedTruncateFailsQueue(t *testing.T) {
+	dir := t.TempDir()
+
+	q := open(t, dir, Options{})
+	push(t, q, 1)
+
+	errTruncate := errors.New("truncate failed")
+	q.head = &faultyFile{File: q.head.(*os.File), truncateErr: errTruncate}
+	if err := q.Push(2); !errors.Is(err, errTruncate) {
+		t.Fatalf("got %v, want %v", err, errTruncate)
+	}
+
+	if err := q.Push(3); !errors.Is(err, ErrFailed) {
+		t.Fatalf("got %v, want %v", err, ErrFailed)
+	}
+	if !errors.Is(q.Err(), errFaulty) {
+		t.Fatalf("got Err %v, want it to wrap %v", q.Err(), errFaulty)
+	}
+	if _, ok := q.Pull(); ok {
+		t.Fatal("want Pull to return false once failed")
+	}
+	q.Stop()
+
+	// the torn record is at the tail, recovered by truncating it
+	q = open(t, dir, Options{})
+	defer q.Stop()
+
+	if got := pullAll(t, q); !slices.Equal(got, []int{1}) {
+		t.Fatalf("got %v, want [1]", got)
+	}
+	if entries, _ := filepath.Glob(filepath.Join(dir, "*"+segmentExt)); len(entries) == 0 {
+		t.Fatal("want the log kept")
+	}
+}
+
+// unsyncedFile fails every sync.
+type unsyncedFile struct {
+	*os.File
+}
+
+var errUnsynced = errors.New("sync failed")
+
+func (f *unsyncedFile) Sync() error {
+	return errUnsynced
+}
+
+func TestFailedIntervalSyncFailsQueue(t *testing.T) {
+	dir := t.TempDir()
+
+	q := open(t, dir, Options{Sync: SyncInterval, SyncInterval: time.Millisecond})
+	defer q.Stop()
+	push(t, q, 1)
+
+	q.mu.Lock()
+	q.head = &unsyncedFile{File: q.head.(*os.File)}
+	q.mu.Unlock()
+	push(t, q, 2)
+
+	deadline := time.Now().Add(time.Second)
+	for !errors.Is(q.Err(), errUnsynced) {
+		if time.Now().After(deadline) {
+			t.Fatalf("got Err %v, want it to wrap %v", q.Err(), errUnsynced)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := q.Push(3); !errors.Is(err, ErrFailed) {
+		t.Fatalf("got %v, want %v", err, ErrFailed)
+	}
+	if _, ok := q.Pull(); ok {
+		t.Fatal("want Pull to return false once failed")
+	}
+}
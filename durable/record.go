@@ -0,0 +1,68 @@
+package durable
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+)
+
+type recordKind uint8
+
+const (
+	recordPush recordKind = iota + 1
+	recordAck
+)
+
+// recordHeader is the length and checksum of the record body that follows it.
+const recordHeader = 8
+
+// recordBody is the kind and sequence that start every record body, a push record is followed by
+// its encoded payload.
+const recordBody = 9
+
+var errCorrupt = errors.New("durable: corrupt record")
+
+// appendRecord appends the encoded record to b.
+func appendRecord(b []byte, kind recordKind, seq uint64, payload []byte) []byte {
+	n := recordBody + len(payload)
+	start := len(b)
+	b = append(b, make([]byte, recordHeader+recordBody)...)
+	b = append(b, payload...)
+
+	binary.LittleEndian.PutUint32(b[start:], uint32(n))
+	body := b[start+recordHeader:]
+	body[0] = byte(kind)
+	binary.LittleEndian.PutUint64(body[1:], seq)
+	binary.LittleEndian.PutUint32(b[start+4:], crc32.ChecksumIEEE(body))
+
+	return b
+}
+
+// readRecord reads the next record from r. io.EOF is returned at a clean end of the log, while
+// errCorrupt or io.ErrUnexpectedEOF indicate a torn or damaged record.
+func readRecord(r io.Reader) (kind recordKind, seq uint64, payload []byte, err error) {
+	var header [recordHeader]byte
+	if _, err = io.ReadFull(r, header[:]); err != nil {
+		return 0, 0, nil, err
+	}
+
+	n := binary.LittleEndian.Uint32(header[:])
+	if n < recordBody {
+		return 0, 0, nil, errCorrupt
+	}
+
+	body := make([]byte, n)
+	if _, err = io.ReadFull(r, body); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, 0, nil, err
+	}
+
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(header[4:]) {
+		return 0, 0, nil, errCorrupt
+	}
+
+	return recordKind(body[0]), binary.LittleEndian.Uint64(body[1:]), body[recordBody:], nil
+}
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/curlymon/pipes"
+	"github.com/curlymon/pipes/agg"
 	"github.com/curlymon/pipes/async"
 )
 
@@ -45,57 +46,59 @@ func main() {
 	resultPipe := pipes.Window(ChanSize, time.Second, compileResult, newResults, filePipe)
 	resultPipe = pipes.Tap(ChanSize, logAny[*Results], resultPipe)
 
-	log.Println(pipes.Reduce(compileResults, &Results{}, resultPipe))
+	log.Println(pipes.Reduce(compileResults, newResults(), resultPipe))
 }
 
 func pipeline(recurse bool, dir string) pipes.ChanPull[*FileInfo] {
-	out := pipes.New[*FileInfo](10)
-
-	go func() {
-		defer out.Close()
-		if err := filepath.WalkDir(dir, walkFunc(dir, recurse, out)); err != nil {
-			log.Printf("error walking directory: dir=%s, err=%s", dir, err)
-		}
-	}()
+	info, err := os.Stat(dir)
+	if err != nil {
+		log.Printf("error walking directory: dir=%s, err=%s", dir, err)
+		return pipes.Empty[*FileInfo]()
+	}
 
-	return out.ChanPull()
-}
+	root := &FileInfo{
+		Path:  dir,
+		Entry: fs.FileInfoToDirEntry(info),
+		Start: time.Now(),
+	}
 
-func walkFunc(dir string, recurse bool, out chan<- *FileInfo) func(string, fs.DirEntry, error) error {
-	return func(path string, d fs.DirEntry, err error) error {
-		if d.IsDir() {
-			// If we have a Directory that has errored: log error; SkipDir
-			if err != nil {
-				log.Printf("path=%s, err=%s\n", path, err)
-				return fs.SkipDir
-			}
+	walked := async.ExpandWithErrorSink(Workers, ChanSize, walkDir(dir, recurse), logError("error walking directory"), pipes.Repeat(1, 1, root))
 
-			// If we have a Directory that is not the starting dir and recurse is disabled: SkipDir
-			if !recurse && dir != path {
-				return fs.SkipDir
-			}
+	// directories are expanded into their entries but only the files are emitted
+	return pipes.Filter(ChanSize, isFile, walked)
+}
 
-			// If we are a Directory that hasn't errored: don't emit; continue walking
-			return nil
+func walkDir(dir string, recurse bool) func(*FileInfo) ([]*FileInfo, error) {
+	return func(fi *FileInfo) ([]*FileInfo, error) {
+		// If we have a normal file, or a Directory that is not the starting dir and recurse is
+		// disabled: nothing to expand
+		if !fi.Entry.IsDir() || (!recurse && dir != fi.Path) {
+			return nil, nil
 		}
 
-		// If we are a normal file that has errored: don't emit; log error; continue walking
+		// If we have a Directory that has errored: log error; skip it
+		entries, err := os.ReadDir(fi.Path)
 		if err != nil {
-			log.Printf("path=%s, err=%s\n", path, err)
-			return nil
+			return nil, fmt.Errorf("path=%s, err=%w", fi.Path, err)
 		}
 
-		// We have a file and we don't seem to have angered the powers that be: emit; continue walking
-		out <- &FileInfo{
-			Path:  path,
-			Entry: d,
-			Start: time.Now(),
+		children := make([]*FileInfo, 0, len(entries))
+		for _, entry := range entries {
+			children = append(children, &FileInfo{
+				Path:  filepath.Join(fi.Path, entry.Name()),
+				Entry: entry,
+				Start: time.Now(),
+			})
 		}
 
-		return nil
+		return children, nil
 	}
 }
 
+func isFile(fi *FileInfo) bool {
+	return !fi.Entry.IsDir()
+}
+
 func getDir(args []string) (string, error) {
 	if len(args) < 2 {
 		return "", errors.New("must pass directory path after binary name")
@@ -117,14 +120,16 @@ func getDir(args []string) (string, error) {
 }
 
 func compileResult(fi *FileInfo, results *Results) *Results {
-	results.Found++
-	results.TotalDuration += time.Since(fi.Start)
+	results.Latency.Add(time.Since(fi.Start))
+	if info, err := fi.Entry.Info(); err == nil {
+		results.Size.Add(info.Size())
+	}
 	return results
 }
 
 func compileResults(result, results *Results) *Results {
-	results.Found += result.Found
-	results.TotalDuration += result.TotalDuration
+	results.Latency.Merge(&result.Latency)
+	results.Size.Merge(result.Size)
 	return results
 }
 
@@ -141,23 +146,24 @@ type FileInfo struct {
 }
 
 type Results struct {
-	Found         int
-	TotalDuration time.Duration
+	Latency agg.Variance[time.Duration]
+	Size    *agg.Histogram[int64]
 }
 
+// sizeBuckets are the upper bounds of the file size histogram.
+var sizeBuckets = []int64{1 << 10, 64 << 10, 1 << 20, 64 << 20, 1 << 30}
+
 func newResults() *Results {
-	return new(Results)
+	return &Results{Size: agg.NewHistogram(sizeBuckets...)}
 }
 
 func (r Results) String() string {
-	count := r.Found
-	if r.Found == 0 {
-		count++
-	}
-
-	avg := time.Duration(float64(r.TotalDuration) / float64(count))
+	found := r.Latency.Count()
+	avg := time.Duration(r.Latency.Mean())
+	dev := time.Duration(r.Latency.StdDev())
+	tot := avg * time.Duration(found)
 
-	return fmt.Sprintf("Processed: %d, Avg: %s, Tot: %s", r.Found, avg, r.TotalDuration)
+	return fmt.Sprintf("Processed: %d, Avg: %s, StdDev: %s, Tot: %s, Sizes: %v", found, avg, dev, tot, r.Size.Counts())
 }
 
 var fileBuffers = sync.Pool{
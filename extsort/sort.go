@@ -0,0 +1,379 @@
+// Package extsort provides an external sort stage able to sort streams far larger than memory by
+// spilling sorted runs to temporary files and merging them.
+package extsort
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/curlymon/pipes"
+	"github.com/curlymon/pipes/codec"
+)
+
+// DefaultLimit is the number of items buffered before spilling when Options sets no limit.
+const DefaultLimit = 1 << 16
+
+// DefaultMaxOpenRuns is the number of run files merged at once when Options sets no MaxOpenRuns.
+const DefaultMaxOpenRuns = 64
+
+// Options configures Sort.
+type Options[T any] struct {
+	// Limit is the number of items buffered in memory before they are sorted and spilled to a run
+	// file.
+	Limit int
+	// Bytes spills once the sizes of the buffered items reported by Size total this many bytes,
+	// whichever of Limit and Bytes is reached first. Ignored if Size is nil.
+	Bytes int
+	Size  func(T) int
+	// Codec encodes items in the run files. Defaults to codec.Gob.
+	Codec codec.Codec
+	// Dir is the directory the run files are created under. Defaults to os.TempDir.
+	Dir string
+	// MaxOpenRuns caps the number of run files open at once. When more runs were spilled they are
+	// first merged in passes into intermediate runs. Values below 2 use DefaultMaxOpenRuns.
+	MaxOpenRuns int
+}
+
+// Sort pushes every T read from in onto the returned channel ordered by compare once in is closed.
+// Equal items keep the order they were read in.
+//
+// Items are buffered in memory up to the limits set by opts, beyond which each buffer is sorted and
+// spilled to a temporary run file using opts.Codec, the runs then being k-way merged at most
+// opts.MaxOpenRuns at a time. A stream fitting within the limits is sorted entirely in memory. Run
+// files are removed once merged and once the sort completes, fails or ctx is done.
+//
+// Any error spilling or reading back a run is pushed onto the returned error channel and ends the
+// sort. When ctx is done both channels are closed without error. In either case the remainder of
+// in is drained in the background to release upstream stages.
+func Sort[T any](ctx context.Context, size int, compare func(a, b T) int, opts Options[T], in <-chan T) (pipes.ChanPull[T], pipes.ChanPull[error]) {
+	out, err := make(chan T, size), make(chan error, size)
+
+	if opts.Limit < 1 && (opts.Bytes < 1 || opts.Size == nil) {
+		opts.Limit = DefaultLimit
+	}
+	if opts.Codec == nil {
+		opts.Codec = codec.Gob
+	}
+	if opts.MaxOpenRuns < 2 {
+		opts.MaxOpenRuns = DefaultMaxOpenRuns
+	}
+
+	go sortWorker(ctx, compare, opts, in, out, err)
+
+	return out, err
+}
+
+// sorter holds the state of a single Sort.
+type sorter[T any] struct {
+	compare func(a, b T) int
+	opts    Options[T]
+	dir     string   // created on the first spill
+	runs    []string // run files in spill order
+	created int      // run files created, numbering their names
+	buf     []T
+	bytes   int
+}
+
+// errCancelled stops a merge once ctx is done.
+var errCancelled = errors.New("extsort: cancelled")
+
+func sortWorker[T any](ctx context.Context, compare func(a, b T) int, opts Options[T], in <-chan T, out chan<- T, err chan<- error) {
+	defer func() { close(out); close(err) }()
+	defer func() { go pipes.ChanPull[T](in).Drain() }()
+
+	s := &sorter[T]{compare: compare, opts: opts}
+	defer s.cleanup()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case t, ok := <-in:
+			if !ok {
+				if er := s.merge(ctx, out); er != nil {
+					err <- er
+				}
+				return
+			}
+
+			if er := s.add(t); er != nil {
+				err <- er
+				return
+			}
+		}
+	}
+}
+
+// add buffers t spilling the buffer once full.
+func (s *sorter[T]) add(t T) error {
+	s.buf = append(s.buf, t)
+	if s.opts.Size != nil {
+		s.bytes += s.opts.Size(t)
+	}
+
+	full := (s.opts.Limit > 0 && len(s.buf) >= s.opts.Limit) ||
+		(s.opts.Size != nil && s.opts.Bytes > 0 && s.bytes >= s.opts.Bytes)
+	if !full {
+		return nil
+	}
+
+	return s.spill()
+}
+
+// spill sorts the buffer and writes it to a new run file.
+func (s *sorter[T]) spill() error {
+	slices.SortStableFunc(s.buf, s.compare)
+
+	name, err := s.write(func(enc codec.Encoder) error {
+		for _, t := range s.buf {
+			if err := enc.Encode(t); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("extsort: spill: %w", err)
+	}
+	s.runs = append(s.runs, name)
+
+	clear(s.buf)
+	s.buf, s.bytes = s.buf[:0], 0
+
+	return nil
+}
+
+// write creates a new run file holding the items encoded by fill, returning its name.
+func (s *sorter[T]) write(fill func(codec.Encoder) error) (string, error) {
+	if s.dir == "" {
+		dir, err := os.MkdirTemp(s.opts.Dir, "pipes-extsort-*")
+		if err != nil {
+			return "", err
+		}
+		s.dir = dir
+	}
+
+	name := filepath.Join(s.dir, fmt.Sprintf("run-%06d", s.created))
+	s.created++
+
+	f, err := os.Create(name)
+	if err != nil {
+		return "", err
+	}
+
+	w := bufio.NewWriter(f)
+	err = fill(s.opts.Codec.NewEncoder(w))
+	if err == nil {
+		err = w.Flush()
+	}
+
+	return name, errors.Join(err, f.Close())
+}
+
+// merge pushes the spilled runs and whatever remains buffered onto out in order, first merging the
+// runs in passes until at most MaxOpenRuns remain.
+func (s *sorter[T]) merge(ctx context.Context, out chan<- T) error {
+	slices.SortStableFunc(s.buf, s.compare)
+
+	for len(s.runs) > s.opts.MaxOpenRuns {
+		if err := s.pass(ctx); err != nil {
+			if errors.Is(err, errCancelled) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	// the final buffer is the last run so equal items stay in the order they were read
+	err := s.mergeRuns(s.runs, s.buf, func(t T) error {
+		select {
+		case out <- t:
+			return nil
+		case <-ctx.Done():
+			return errCancelled
+		}
+	})
+	if errors.Is(err, errCancelled) {
+		return nil
+	}
+
+	return err
+}
+
+// pass merges each group of MaxOpenRuns consecutive runs into a single intermediate run, removing
+// the runs merged. Only consecutive runs are merged so that equal items keep their order.
+func (s *sorter[T]) pass(ctx context.Context) error {
+	runs := make([]string, 0, (len(s.runs)+s.opts.MaxOpenRuns-1)/s.opts.MaxOpenRuns)
+	for group := range slices.Chunk(s.runs, s.opts.MaxOpenRuns) {
+		name, err := s.write(func(enc codec.Encoder) error {
+			return s.mergeRuns(group, nil, func(t T) error {
+				if ctx.Err() != nil {
+					return errCancelled
+				}
+				return enc.Encode(t)
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("extsort: merge: %w", err)
+		}
+		runs = append(runs, name)
+
+		for _, run := range group {
+			os.Remove(run)
+		}
+	}
+	s.runs = runs
+
+	return nil
+}
+
+// mergeRuns k-way merges the run files followed by the in memory run tail, passing each item in
+// order to emit. Each run file is closed as soon as it is exhausted.
+func (s *sorter[T]) mergeRuns(runs []string, tail []T, emit func(T) error) error {
+	h := &mergeHeap[T]{compare: s.compare}
+	defer h.close()
+
+	for i, name := range runs {
+		f, err := os.Open(name)
+		if err != nil {
+			return fmt.Errorf("extsort: merge: %w", err)
+		}
+
+		c := &cursor[T]{run: i, name: name, file: f, next: fileRun[T](s.opts.Codec.NewDecoder(bufio.NewReader(f)))}
+		if err = h.push(c); err != nil {
+			return fmt.Errorf("extsort: merge: %s: %w", filepath.Base(name), err)
+		}
+	}
+
+	if err := h.push(&cursor[T]{run: len(runs), next: memoryRun(tail)}); err != nil {
+		return err
+	}
+
+	for h.Len() > 0 {
+		c := h.cursors[0]
+		if err := emit(c.head); err != nil {
+			return err
+		}
+
+		t, ok, err := c.next()
+		if err != nil {
+			return fmt.Errorf("extsort: merge: %s: %w", filepath.Base(c.name), err)
+		}
+		if !ok {
+			heap.Pop(h)
+			c.close()
+			continue
+		}
+
+		c.head = t
+		heap.Fix(h, 0)
+	}
+
+	return nil
+}
+
+// cleanup removes any run files.
+func (s *sorter[T]) cleanup() {
+	if s.dir != "" {
+		os.RemoveAll(s.dir)
+	}
+}
+
+// fileRun returns the next func of a run read back from a file by dec.
+func fileRun[T any](dec codec.Decoder) func() (T, bool, error) {
+	return func() (T, bool, error) {
+		var t T
+		if err := dec.Decode(&t); err != nil {
+			if errors.Is(err, io.EOF) {
+				return t, false, nil
+			}
+			return t, false, err
+		}
+
+		return t, true, nil
+	}
+}
+
+// memoryRun returns the next func of a run held in memory.
+func memoryRun[T any](ts []T) func() (T, bool, error) {
+	return func() (T, bool, error) {
+		var t T
+		if len(ts) == 0 {
+			return t, false, nil
+		}
+
+		t, ts = ts[0], ts[1:]
+		return t, true, nil
+	}
+}
+
+// cursor is the head of a single run being merged.
+type cursor[T any] struct {
+	head T
+	run  int
+	name string
+	file *os.File // nil for a run held in memory
+	next func() (T, bool, error)
+}
+
+// close closes the run's file, if any.
+func (c *cursor[T]) close() {
+	if c.file != nil {
+		c.file.Close()
+	}
+}
+
+// mergeHeap is a min heap of run cursors by head, ties broken by run to keep the sort stable.
+type mergeHeap[T any] struct {
+	compare func(a, b T) int
+	cursors []*cursor[T]
+}
+
+func (h *mergeHeap[T]) Len() int { return len(h.cursors) }
+
+func (h *mergeHeap[T]) Less(i, j int) bool {
+	if c := h.compare(h.cursors[i].head, h.cursors[j].head); c != 0 {
+		return c < 0
+	}
+
+	return h.cursors[i].run < h.cursors[j].run
+}
+
+func (h *mergeHeap[T]) Swap(i, j int) { h.cursors[i], h.cursors[j] = h.cursors[j], h.cursors[i] }
+
+func (h *mergeHeap[T]) Push(x any) { h.cursors = append(h.cursors, x.(*cursor[T])) }
+
+func (h *mergeHeap[T]) Pop() any {
+	c := h.cursors[len(h.cursors)-1]
+	h.cursors[len(h.cursors)-1] = nil
+	h.cursors = h.cursors[:len(h.cursors)-1]
+	return c
+}
+
+// push adds c to the heap at the first item of its run, closing it instead if the run is empty.
+func (h *mergeHeap[T]) push(c *cursor[T]) error {
+	t, ok, err := c.next()
+	if err != nil || !ok {
+		c.close()
+		return err
+	}
+
+	c.head = t
+	heap.Push(h, c)
+	return nil
+}
+
+// close closes the runs not yet exhausted.
+func (h *mergeHeap[T]) close() {
+	for _, c := range h.cursors {
+		c.close()
+	}
+}
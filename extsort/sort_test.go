@@ -0,0 +1,123 @@
+package extsort
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/curlymon/pipes"
+	"github.com/curlymon/pipes/codec"
+)
+
+// item is sorted by Key alone, Seq recording the order it was read in to check stability.
+type item struct {
+	Key int
+	Seq int
+}
+
+func byKey(a, b item) int {
+	return cmp.Compare(a.Key, b.Key)
+}
+
+func items(n int) []item {
+	r := rand.New(rand.NewPCG(1, 2))
+
+	ts := make([]item, n)
+	for i := range ts {
+		ts[i] = item{Key: r.IntN(n / 4), Seq: i}
+	}
+
+	return ts
+}
+
+func sorted(t *testing.T, ctx context.Context, opts Options[item], in []item) []item {
+	t.Helper()
+
+	// buffered so that an error does not block the sort closing out
+	out, errs := Sort(ctx, 1, byKey, opts, pipes.FromSlice(0, in))
+	got := pipes.ToSlice(out)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	return got
+}
+
+func expectEmpty(t *testing.T, dir string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("got %d entries left in %s, want the run files removed", len(entries), dir)
+	}
+}
+
+func TestSort(t *testing.T) {
+	for name, opts := range map[string]Options[item]{
+		"memory":    {},
+		"spilled":   {Limit: 10},
+		"bytes":     {Bytes: 100, Size: func(item) int { return 16 }},
+		"json":      {Limit: 10, Codec: codec.JSON},
+		"multipass": {Limit: 3, MaxOpenRuns: 2},
+	} {
+		t.Run(name, func(t *testing.T) {
+			opts.Dir = t.TempDir()
+
+			in := items(1000)
+			got := sorted(t, context.Background(), opts, in)
+
+			want := slices.Clone(in)
+			slices.SortStableFunc(want, byKey)
+			if !slices.Equal(got, want) {
+				t.Fatalf("not sorted stably, got %v", got)
+			}
+
+			expectEmpty(t, opts.Dir)
+		})
+	}
+}
+
+func TestSortEmpty(t *testing.T) {
+	if got := sorted(t, context.Background(), Options[item]{Limit: 10}, nil); len(got) != 0 {
+		t.Fatalf("got %v, want nothing", got)
+	}
+}
+
+func TestSortSpillError(t *testing.T) {
+	dir := t.TempDir()
+
+	// JSON can't encode NaN
+	in := []float64{3, 1, math.NaN(), 2}
+	out, errs := Sort(context.Background(), 0, cmp.Compare[float64], Options[float64]{Limit: 2, Codec: codec.JSON, Dir: dir}, pipes.FromSlice(0, in))
+
+	go out.Drain()
+	if n := len(pipes.ToSlice(errs)); n != 1 {
+		t.Fatalf("got %d errors, want 1", n)
+	}
+
+	expectEmpty(t, dir)
+}
+
+func TestSortCancelled(t *testing.T) {
+	dir := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out, errs := Sort(ctx, 0, byKey, Options[item]{Limit: 10, MaxOpenRuns: 4, Dir: dir}, pipes.FromSlice(0, items(1000)))
+
+	<-out
+	cancel()
+
+	out.Drain()
+	for err := range errs {
+		t.Fatalf("got %v, want no error once cancelled", err)
+	}
+
+	expectEmpty(t, dir)
+}
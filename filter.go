@@ -1,59 +1,92 @@
 package pipes
 
 func Filter[T any](size int, filter func(T) bool, in <-chan T) ChanPull[T] {
+	return FilterWithMetrics(nil, "", size, filter, in)
+}
+
+// FilterWithMetrics behaves as Filter reporting the activity of its worker to m under the given
+// stage name. Only items kept are counted as out.
+func FilterWithMetrics[T any](m Metrics, stage string, size int, filter func(T) bool, in <-chan T) ChanPull[T] {
 	out := make(chan T, size)
 
-	go filterWorker(filter, in, out)
+	go filterWorker(NewMeter(m, stage), filter, in, out)
 
 	return out
 }
 
-func filterWorker[T any](filter func(T) bool, in <-chan T, out ChanPush[T]) {
+func filterWorker[T any](mt *Meter, filter func(T) bool, in <-chan T, out ChanPush[T]) {
 	defer close(out)
 
 	for t := range in {
-		if filter(t) {
-			out <- t
+		start := mt.Received()
+		keep := filter(t)
+		mt.Processed(start, nil)
+		if keep {
+			MeterSend(mt, out, t)
 		}
 	}
 }
 
 func FilterWithError[T any](size int, filter func(T) (bool, error), in <-chan T) (ChanPull[T], ChanPull[error]) {
+	return FilterWithErrorMetrics(nil, "", size, filter, in)
+}
+
+// FilterWithErrorMetrics behaves as FilterWithError reporting the activity of its worker to m under
+// the given stage name. Only items kept are counted as out.
+func FilterWithErrorMetrics[T any](m Metrics, stage string, size int, filter func(T) (bool, error), in <-chan T) (ChanPull[T], ChanPull[error]) {
 	out, err := make(chan T, size), make(chan error, size)
 
-	go filterWithErrorWorker(filter, in, out, err)
+	go filterWithErrorWorker(NewMeter(m, stage), filter, in, out, err)
 
 	return out, err
 }
 
-func filterWithErrorWorker[T any](filter func(T) (bool, error), in <-chan T, out chan<- T, err chan<- error) {
+func filterWithErrorWorker[T any](mt *Meter, filter func(T) (bool, error), in <-chan T, out chan<- T, err chan<- error) {
 	defer func() { close(out); close(err) }()
 
 	for t := range in {
-		if keep, er := filter(t); er != nil {
+		start := mt.Received()
+		keep, er := filter(t)
+		mt.Processed(start, er)
+		if er != nil {
 			err <- er
+			if stopping(er, in) {
+				return
+			}
 		} else if keep {
-			out <- t
+			MeterSend(mt, out, t)
 		}
 	}
 }
 
 func FilterWithErrorSink[T any](size int, filter func(T) (bool, error), sink func(error), in <-chan T) ChanPull[T] {
+	return FilterWithErrorSinkMetrics(nil, "", size, filter, sink, in)
+}
+
+// FilterWithErrorSinkMetrics behaves as FilterWithErrorSink reporting the activity of its worker to
+// m under the given stage name. Only items kept are counted as out.
+func FilterWithErrorSinkMetrics[T any](m Metrics, stage string, size int, filter func(T) (bool, error), sink func(error), in <-chan T) ChanPull[T] {
 	out := make(chan T, size)
 
-	go filterWithErrorSinkWorker(filter, sink, in, out)
+	go filterWithErrorSinkWorker(NewMeter(m, stage), filter, sink, in, out)
 
 	return out
 }
 
-func filterWithErrorSinkWorker[T any](filter func(T) (bool, error), sink func(error), in <-chan T, out chan<- T) {
+func filterWithErrorSinkWorker[T any](mt *Meter, filter func(T) (bool, error), sink func(error), in <-chan T, out chan<- T) {
 	defer close(out)
 
 	for t := range in {
-		if keep, er := filter(t); er != nil {
+		start := mt.Received()
+		keep, er := filter(t)
+		mt.Processed(start, er)
+		if er != nil {
 			sink(er)
+			if stopping(er, in) {
+				return
+			}
 		} else if keep {
-			out <- t
+			MeterSend(mt, out, t)
 		}
 	}
 }
@@ -0,0 +1,123 @@
+package pipes
+
+import "iter"
+
+// FlatMap pushes each N of the slice mp returns for every T read from in onto the returned channel
+// in order.
+func FlatMap[T any, N any](size int, mp func(T) []N, in <-chan T) ChanPull[N] {
+	out := make(chan N, size)
+
+	go flatMapWorker(mp, in, out)
+
+	return out
+}
+
+func flatMapWorker[T any, N any](mp func(T) []N, in <-chan T, out chan<- N) {
+	defer close(out)
+
+	for t := range in {
+		for _, n := range mp(t) {
+			out <- n
+		}
+	}
+}
+
+func FlatMapWithError[T any, N any](size int, mp func(T) ([]N, error), in <-chan T) (ChanPull[N], ChanPull[error]) {
+	out, err := make(chan N, size), make(chan error, size)
+
+	go flatMapWithErrorWorker(mp, in, out, err)
+
+	return out, err
+}
+
+func flatMapWithErrorWorker[T any, N any](mp func(T) ([]N, error), in <-chan T, out chan<- N, err chan<- error) {
+	defer func() { close(out); close(err) }()
+
+	for t := range in {
+		ns, er := mp(t)
+		if er != nil {
+			err <- er
+			if stopping(er, in) {
+				return
+			}
+			continue
+		}
+
+		for _, n := range ns {
+			out <- n
+		}
+	}
+}
+
+func FlatMapWithErrorSink[T any, N any](size int, mp func(T) ([]N, error), sink func(error), in <-chan T) ChanPull[N] {
+	out := make(chan N, size)
+
+	go flatMapWithErrorSinkWorker(mp, sink, in, out)
+
+	return out
+}
+
+func flatMapWithErrorSinkWorker[T any, N any](mp func(T) ([]N, error), sink func(error), in <-chan T, out chan<- N) {
+	defer close(out)
+
+	for t := range in {
+		ns, er := mp(t)
+		if er != nil {
+			sink(er)
+			if stopping(er, in) {
+				return
+			}
+			continue
+		}
+
+		for _, n := range ns {
+			out <- n
+		}
+	}
+}
+
+// FlatMapSeq pushes each N yielded by the iterator mp returns for every T read from in onto the
+// returned channel in order.
+func FlatMapSeq[T any, N any](size int, mp func(T) iter.Seq[N], in <-chan T) ChanPull[N] {
+	out := make(chan N, size)
+
+	go flatMapSeqWorker(mp, in, out)
+
+	return out
+}
+
+func flatMapSeqWorker[T any, N any](mp func(T) iter.Seq[N], in <-chan T, out chan<- N) {
+	defer close(out)
+
+	for t := range in {
+		for n := range mp(t) {
+			out <- n
+		}
+	}
+}
+
+// FlatMapChan pushes each N read from the channel mp returns for every T read from in onto the
+// returned channel. Each returned channel is read until closed before the next T is read, a nil
+// channel is skipped.
+func FlatMapChan[T any, N any](size int, mp func(T) ChanPull[N], in <-chan T) ChanPull[N] {
+	out := make(chan N, size)
+
+	go flatMapChanWorker(mp, in, out)
+
+	return out
+}
+
+func flatMapChanWorker[T any, N any](mp func(T) ChanPull[N], in <-chan T, out chan<- N) {
+	defer close(out)
+
+	for t := range in {
+		ns := mp(t)
+		if ns == nil {
+			continue
+		}
+
+		for n := range ns {
+			out <- n
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package pipes
+
+import (
+	"container/list"
+	"time"
+)
+
+// Group is a sub-stream of the items sharing Key, announced by GroupBy the first time the key is
+// seen.
+type Group[K comparable, T any] struct {
+	Key   K
+	Items ChanPull[T]
+}
+
+// GroupOptions controls how long GroupBy keeps groups open.
+type GroupOptions struct {
+	// Idle closes a group once no item has been routed to it for this long, zero keeps groups open
+	// until in is closed.
+	Idle time.Duration
+	// MaxOpen caps the number of open groups, closing the least recently used group to make room
+	// for a new key. Zero places no cap.
+	MaxOpen int
+	// Clock is the source of time for Idle, nil uses SystemClock.
+	Clock Clock
+}
+
+// GroupBy routes each T read from in to a sub-stream for its key, creating and announcing a Group on
+// the returned channel the first time a key is seen. A key seen again after its group was closed,
+// by Idle or MaxOpen, is announced as a new Group. Every group is closed when in is closed.
+//
+// Unlike Router the set of keys does not need to be known up front. Announcing a group and routing
+// to it both block, so the returned channel and every announced group's Items must be read.
+func GroupBy[T any, K comparable](size int, key func(T) K, opts GroupOptions, in <-chan T) ChanPull[Group[K, T]] {
+	out := make(chan Group[K, T], size)
+
+	go groupByWorker(size, key, opts, clockOrSystem(opts.Clock), in, out)
+
+	return out
+}
+
+// openGroup is an announced Group held in least recently used order.
+type openGroup[K comparable, T any] struct {
+	key  K
+	out  chan T
+	last time.Time
+}
+
+func groupByWorker[T any, K comparable](size int, key func(T) K, opts GroupOptions, clock Clock, in <-chan T, out chan<- Group[K, T]) {
+	// front is the least recently used group
+	lru := list.New()
+	groups := make(map[K]*list.Element)
+
+	closeGroup := func(e *list.Element) {
+		g := lru.Remove(e).(*openGroup[K, T])
+		delete(groups, g.key)
+		close(g.out)
+	}
+
+	defer func() {
+		for e := lru.Front(); e != nil; e = lru.Front() {
+			closeGroup(e)
+		}
+
+		close(out)
+	}()
+
+	var timer Timer
+	var expired <-chan time.Time
+	if opts.Idle > 0 {
+		timer = clock.NewTimer(opts.Idle)
+		timer.Stop()
+		defer timer.Stop()
+		expired = timer.C()
+	}
+
+	// rearm points the timer at the least recently used group, the next to go idle
+	rearm := func() {
+		if timer == nil {
+			return
+		}
+
+		timer.Stop()
+		if e := lru.Front(); e != nil {
+			timer.Reset(e.Value.(*openGroup[K, T]).last.Add(opts.Idle).Sub(clock.Now()))
+		}
+	}
+
+	for {
+		select {
+		case t, ok := <-in:
+			if !ok {
+				return
+			}
+
+			k := key(t)
+			e, exists := groups[k]
+			if exists {
+				lru.MoveToBack(e)
+			} else {
+				if opts.MaxOpen > 0 && lru.Len() >= opts.MaxOpen {
+					closeGroup(lru.Front())
+				}
+
+				g := &openGroup[K, T]{key: k, out: make(chan T, size)}
+				e = lru.PushBack(g)
+				groups[k] = e
+				out <- Group[K, T]{Key: k, Items: g.out}
+			}
+
+			g := e.Value.(*openGroup[K, T])
+			g.last = clock.Now()
+			g.out <- t
+			rearm()
+
+		case <-expired:
+			now := clock.Now()
+			for e := lru.Front(); e != nil; e = lru.Front() {
+				if now.Sub(e.Value.(*openGroup[K, T]).last) < opts.Idle {
+					break
+				}
+				closeGroup(e)
+			}
+			rearm()
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package pipes
+
+import (
+	"testing"
+	"time"
+)
+
+func firstByte(s string) byte { return s[0] }
+
+func expectGroup(t *testing.T, out <-chan Group[byte, string], key byte) Group[byte, string] {
+	t.Helper()
+
+	var g Group[byte, string]
+	select {
+	case g = <-out:
+	case <-time.After(time.Second):
+		t.Fatalf("got no group, want %q", key)
+	}
+	if g.Key != key {
+		t.Fatalf("got group %q, want %q", g.Key, key)
+	}
+
+	return g
+}
+
+func expectOpen[T any](t *testing.T, in <-chan T) {
+	t.Helper()
+
+	select {
+	case got, ok := <-in:
+		t.Fatalf("got %v, %v, want an open channel with nothing queued", got, ok)
+	default:
+	}
+}
+
+func TestGroupByClosesIdleGroups(t *testing.T) {
+	clock := NewManualClock(epoch)
+	in := make(chan string)
+	out := GroupBy(10, firstByte, GroupOptions{Idle: 10 * time.Second, Clock: clock}, in)
+
+	in <- "a1"
+	a := expectGroup(t, out, 'a')
+	expect(t, a.Items, "a1")
+	waitArmed(t, clock, 10*time.Second)
+
+	clock.Advance(6 * time.Second)
+	in <- "b1"
+	b := expectGroup(t, out, 'b')
+	expect(t, b.Items, "b1")
+
+	// a is used again, b is now the next to go idle
+	clock.Advance(2 * time.Second)
+	in <- "a2"
+	expect(t, a.Items, "a2")
+	waitArmed(t, clock, 16*time.Second)
+
+	clock.Advance(8 * time.Second)
+	waitArmed(t, clock, 18*time.Second)
+	expectClosed(t, b.Items)
+	expectOpen(t, a.Items)
+
+	clock.Advance(2 * time.Second)
+	expectClosed(t, a.Items)
+
+	// a key seen after its group closed opens a new group
+	in <- "a3"
+	a = expectGroup(t, out, 'a')
+	expect(t, a.Items, "a3")
+
+	close(in)
+	expectClosed(t, a.Items)
+	expectClosed(t, out)
+}
+
+func TestGroupByEvictsLeastRecentlyUsed(t *testing.T) {
+	in := make(chan string)
+	out := GroupBy(10, firstByte, GroupOptions{MaxOpen: 2}, in)
+
+	in <- "a1"
+	a := expectGroup(t, out, 'a')
+	in <- "b1"
+	b := expectGroup(t, out, 'b')
+	in <- "a2"
+
+	// b is the least recently used group and makes room for c
+	in <- "c1"
+	c := expectGroup(t, out, 'c')
+	expect(t, b.Items, "b1")
+	expectClosed(t, b.Items)
+	expect(t, a.Items, "a1")
+	expect(t, a.Items, "a2")
+	expectOpen(t, a.Items)
+
+	// b reopens as a new group, evicting a
+	in <- "b2"
+	b = expectGroup(t, out, 'b')
+	expect(t, b.Items, "b2")
+	expectClosed(t, a.Items)
+
+	close(in)
+	expect(t, c.Items, "c1")
+	expectClosed(t, c.Items)
+	expectClosed(t, b.Items)
+	expectClosed(t, out)
+}
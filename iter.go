@@ -0,0 +1,93 @@
+package pipes
+
+import (
+	"context"
+	"iter"
+)
+
+// KV is a key value pair as yielded by an iter.Seq2.
+type KV[K any, V any] struct {
+	Key   K
+	Value V
+}
+
+// FromSeq pushes each T yielded by seq onto the returned channel, closing it once seq is exhausted.
+// Cancelling ctx stops seq at the next yield and closes the returned channel, releasing any
+// resources held by the iterator.
+func FromSeq[T any](ctx context.Context, size int, seq iter.Seq[T]) ChanPull[T] {
+	out := make(chan T, size)
+
+	go fromSeqWorker(ctx, seq, out)
+
+	return out
+}
+
+func fromSeqWorker[T any](ctx context.Context, seq iter.Seq[T], out chan<- T) {
+	defer close(out)
+
+	for t := range seq {
+		// select picks at random while out has room, so ctx is checked first
+		if ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case out <- t:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// FromSeq2 pushes each pair yielded by seq onto the returned channel as a KV, closing it once seq
+// is exhausted. Cancelling ctx stops seq at the next yield and closes the returned channel,
+// releasing any resources held by the iterator.
+func FromSeq2[K any, V any](ctx context.Context, size int, seq iter.Seq2[K, V]) ChanPull[KV[K, V]] {
+	out := make(chan KV[K, V], size)
+
+	go fromSeq2Worker(ctx, seq, out)
+
+	return out
+}
+
+func fromSeq2Worker[K any, V any](ctx context.Context, seq iter.Seq2[K, V], out chan<- KV[K, V]) {
+	defer close(out)
+
+	for k, v := range seq {
+		// select picks at random while out has room, so ctx is checked first
+		if ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case out <- KV[K, V]{Key: k, Value: v}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Seq returns an iterator yielding each T read from in until in is closed. Stopping iteration early
+// leaves any remaining items on in for another reader, call Drain if they should be discarded.
+func Seq[T any](in <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for t := range in {
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// Seq2 returns an iterator yielding each KV read from in as a key value pair until in is closed.
+// Stopping iteration early leaves any remaining items on in for another reader, call Drain if they
+// should be discarded.
+func Seq2[K any, V any](in <-chan KV[K, V]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for kv := range in {
+			if !yield(kv.Key, kv.Value) {
+				return
+			}
+		}
+	}
+}
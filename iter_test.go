@@ -0,0 +1,58 @@
+package pipes
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestChanPullSeqCollects(t *testing.T) {
+	got := slices.Collect(FromSlice(2, []int{1, 2, 3}).Seq())
+	if !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("got %v, want [1 2 3]", got)
+	}
+}
+
+func TestFromSeqStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	yielded := make(chan int, 100)
+
+	// every item after the cancel would fit in out, only the ctx check stops them being sent
+	out := FromSeq(ctx, 100, func(yield func(int) bool) {
+		for i := 0; i < 100; i++ {
+			if i == 3 {
+				cancel()
+			}
+			yielded <- i
+			if !yield(i) {
+				return
+			}
+		}
+	})
+
+	if got := ToSlice(out); !slices.Equal(got, []int{0, 1, 2}) {
+		t.Fatalf("got %v, want [0 1 2]", got)
+	}
+	if n := len(yielded); n != 4 {
+		t.Fatalf("seq yielded %d items, want 4", n)
+	}
+}
+
+func TestFromSeq2StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	out := FromSeq2(ctx, 100, func(yield func(int, string) bool) {
+		for i := 0; i < 100; i++ {
+			if i == 3 {
+				cancel()
+			}
+			if !yield(i, "") {
+				return
+			}
+		}
+	})
+
+	if got := ToSlice(out); len(got) != 3 {
+		t.Fatalf("got %v, want 3 items", got)
+	}
+}
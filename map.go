@@ -1,57 +1,90 @@
 package pipes
 
 func Map[T any, N any](size int, mp func(T) N, in <-chan T) ChanPull[N] {
+	return MapWithMetrics(nil, "", size, mp, in)
+}
+
+// MapWithMetrics behaves as Map reporting the activity of its worker to m under the given stage
+// name.
+func MapWithMetrics[T any, N any](m Metrics, stage string, size int, mp func(T) N, in <-chan T) ChanPull[N] {
 	out := make(chan N, size)
 
-	go mapWorker(mp, in, out)
+	go mapWorker(NewMeter(m, stage), mp, in, out)
 
 	return out
 }
 
-func mapWorker[T any, N any](mp func(T) N, in <-chan T, out chan<- N) {
+func mapWorker[T any, N any](mt *Meter, mp func(T) N, in <-chan T, out chan<- N) {
 	defer close(out)
 
 	for t := range in {
-		out <- mp(t)
+		start := mt.Received()
+		n := mp(t)
+		mt.Processed(start, nil)
+		MeterSend(mt, out, n)
 	}
 }
 
 func MapWithError[T any, N any](size int, mp func(T) (N, error), in <-chan T) (ChanPull[N], ChanPull[error]) {
+	return MapWithErrorMetrics(nil, "", size, mp, in)
+}
+
+// MapWithErrorMetrics behaves as MapWithError reporting the activity of its worker to m under the
+// given stage name.
+func MapWithErrorMetrics[T any, N any](m Metrics, stage string, size int, mp func(T) (N, error), in <-chan T) (ChanPull[N], ChanPull[error]) {
 	out, err := make(chan N, size), make(chan error, size)
 
-	go mapWithErrorWorker(mp, in, out, err)
+	go mapWithErrorWorker(NewMeter(m, stage), mp, in, out, err)
 
 	return out, err
 }
 
-func mapWithErrorWorker[T any, N any](mp func(T) (N, error), in <-chan T, out chan<- N, err chan<- error) {
+func mapWithErrorWorker[T any, N any](mt *Meter, mp func(T) (N, error), in <-chan T, out chan<- N, err chan<- error) {
 	defer func() { close(out); close(err) }()
 
 	for t := range in {
-		if n, er := mp(t); er != nil {
+		start := mt.Received()
+		n, er := mp(t)
+		mt.Processed(start, er)
+		if er != nil {
 			err <- er
+			if stopping(er, in) {
+				return
+			}
 		} else {
-			out <- n
+			MeterSend(mt, out, n)
 		}
 	}
 }
 
 func MapWithErrorSink[T any, N any](size int, mp func(T) (N, error), sink func(error), in <-chan T) ChanPull[N] {
+	return MapWithErrorSinkMetrics(nil, "", size, mp, sink, in)
+}
+
+// MapWithErrorSinkMetrics behaves as MapWithErrorSink reporting the activity of its worker to m
+// under the given stage name.
+func MapWithErrorSinkMetrics[T any, N any](m Metrics, stage string, size int, mp func(T) (N, error), sink func(error), in <-chan T) ChanPull[N] {
 	out := make(chan N, size)
 
-	go mapWithErrorSinkWorker(mp, sink, in, out)
+	go mapWithErrorSinkWorker(NewMeter(m, stage), mp, sink, in, out)
 
 	return out
 }
 
-func mapWithErrorSinkWorker[T any, N any](mp func(T) (N, error), sink func(error), in <-chan T, out chan<- N) {
+func mapWithErrorSinkWorker[T any, N any](mt *Meter, mp func(T) (N, error), sink func(error), in <-chan T, out chan<- N) {
 	defer close(out)
 
 	for t := range in {
-		if n, er := mp(t); er != nil {
+		start := mt.Received()
+		n, er := mp(t)
+		mt.Processed(start, er)
+		if er != nil {
 			sink(er)
+			if stopping(er, in) {
+				return
+			}
 		} else {
-			out <- n
+			MeterSend(mt, out, n)
 		}
 	}
 }
@@ -0,0 +1,86 @@
+package pipes
+
+import "time"
+
+// Metrics is the instrumentation hook invoked from within the workers of measured stages. Every
+// call is keyed by the stage name the stage was created with, allowing a single Metrics
+// implementation to be shared across an entire pipeline. Implementations must be safe for
+// concurrent use as async stages invoke the hook from multiple workers at once.
+type Metrics interface {
+	// ItemIn is called when a stage receives an item.
+	ItemIn(stage string)
+	// ItemOut is called when a stage has pushed an item downstream, or for sinks consumed it. Items
+	// dropped by a filter or failing with an error are not counted.
+	ItemOut(stage string)
+	// Error is called when a stage failed to process an item.
+	Error(stage string)
+	// Latency is called with the time taken to process a single item.
+	Latency(stage string, d time.Duration)
+	// Blocked is called with the time spent waiting to push an item downstream.
+	Blocked(stage string, d time.Duration)
+	// Depth is called with the len and cap of a stage's output channel after each push.
+	Depth(stage string, len, cap int)
+}
+
+// Meter reports the activity of a single stage's workers to Metrics. A nil Meter reports nothing,
+// which is how the uninstrumented stages share their workers with the measured ones.
+//
+// Meter is exported for stages in other packages, such as async, it is not needed to use the
+// measured stages themselves.
+type Meter struct {
+	stage string
+	m     Metrics
+}
+
+// NewMeter returns a Meter reporting to m under the given stage name, nil if m is nil.
+func NewMeter(m Metrics, stage string) *Meter {
+	if m == nil {
+		return nil
+	}
+
+	return &Meter{stage: stage, m: m}
+}
+
+// Received reports an item received, returning the start time to pass to Processed.
+func (mt *Meter) Received() time.Time {
+	if mt == nil {
+		return time.Time{}
+	}
+
+	mt.m.ItemIn(mt.stage)
+	return time.Now()
+}
+
+// Processed reports the latency of processing an item received at start along with any error.
+func (mt *Meter) Processed(start time.Time, err error) {
+	if mt == nil {
+		return
+	}
+
+	mt.m.Latency(mt.stage, time.Since(start))
+	if err != nil {
+		mt.m.Error(mt.stage)
+	}
+}
+
+// Consumed reports an item consumed by a sink, the sink equivalent of MeterSend.
+func (mt *Meter) Consumed() {
+	if mt != nil {
+		mt.m.ItemOut(mt.stage)
+	}
+}
+
+// MeterSend pushes t onto out reporting the time spent blocked, the item out and the depth of out
+// to mt.
+func MeterSend[T any](mt *Meter, out chan<- T, t T) {
+	if mt == nil {
+		out <- t
+		return
+	}
+
+	start := time.Now()
+	out <- t
+	mt.m.Blocked(mt.stage, time.Since(start))
+	mt.m.ItemOut(mt.stage)
+	mt.m.Depth(mt.stage, len(out), cap(out))
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import "expvar"
+
+// Publish exports the metrics collected by m through the standard library expvar package under the
+// given name. Each stage is reported as a JSON object alongside its average latency. As with
+// expvar.Publish this panics if name is already in use.
+func Publish(name string, m *Memory) {
+	expvar.Publish(name, expvar.Func(func() any {
+		snap := m.Snapshot()
+		vars := make(map[string]any, len(snap))
+		for stage, s := range snap {
+			vars[stage] = map[string]any{
+				"in":          s.In,
+				"out":         s.Out,
+				"errors":      s.Errors,
+				"latency_ns":  int64(s.Latency),
+				"avg_latency": s.AvgLatency().String(),
+				"max_latency": s.MaxLatency.String(),
+				"blocked_ns":  int64(s.Blocked),
+				"max_blocked": s.MaxBlocked.String(),
+				"len":         s.Len,
+				"cap":         s.Cap,
+			}
+		}
+		return vars
+	}))
+}
@@ -0,0 +1,139 @@
+// Package metrics provides implementations of the pipes.Metrics instrumentation hook.
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/curlymon/pipes"
+)
+
+var _ pipes.Metrics = (*Memory)(nil)
+
+// Stage is a point in time snapshot of the metrics collected for a single stage.
+type Stage struct {
+	In         uint64
+	Out        uint64
+	Errors     uint64
+	Latency    time.Duration // total time spent processing items
+	MaxLatency time.Duration
+	Blocked    time.Duration // total time spent blocked pushing items downstream
+	MaxBlocked time.Duration
+	Len        int // last observed len of the stage's output channel
+	Cap        int // last observed cap of the stage's output channel
+}
+
+// AvgLatency returns the mean time taken to process a single item.
+func (s Stage) AvgLatency() time.Duration {
+	if s.In > 0 {
+		return s.Latency / time.Duration(s.In)
+	}
+
+	return 0
+}
+
+// Memory is an in-memory pipes.Metrics implementation. The zero value is ready to use.
+type Memory struct {
+	mu     sync.Mutex
+	stages map[string]*Stage
+}
+
+// NewMemory returns a new empty Memory.
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// stage returns the stats for the named stage creating them as needed. The caller must hold mu.
+func (m *Memory) stage(name string) *Stage {
+	if m.stages == nil {
+		m.stages = make(map[string]*Stage)
+	}
+
+	s, ok := m.stages[name]
+	if !ok {
+		s = &Stage{}
+		m.stages[name] = s
+	}
+
+	return s
+}
+
+func (m *Memory) ItemIn(stage string) {
+	m.mu.Lock()
+	m.stage(stage).In++
+	m.mu.Unlock()
+}
+
+func (m *Memory) ItemOut(stage string) {
+	m.mu.Lock()
+	m.stage(stage).Out++
+	m.mu.Unlock()
+}
+
+func (m *Memory) Error(stage string) {
+	m.mu.Lock()
+	m.stage(stage).Errors++
+	m.mu.Unlock()
+}
+
+func (m *Memory) Latency(stage string, d time.Duration) {
+	m.mu.Lock()
+	s := m.stage(stage)
+	s.Latency += d
+	if d > s.MaxLatency {
+		s.MaxLatency = d
+	}
+	m.mu.Unlock()
+}
+
+func (m *Memory) Blocked(stage string, d time.Duration) {
+	m.mu.Lock()
+	s := m.stage(stage)
+	s.Blocked += d
+	if d > s.MaxBlocked {
+		s.MaxBlocked = d
+	}
+	m.mu.Unlock()
+}
+
+func (m *Memory) Depth(stage string, len, cap int) {
+	m.mu.Lock()
+	s := m.stage(stage)
+	s.Len, s.Cap = len, cap
+	m.mu.Unlock()
+}
+
+// Snapshot returns a copy of the metrics collected so far keyed by stage name.
+func (m *Memory) Snapshot() map[string]Stage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snap := make(map[string]Stage, len(m.stages))
+	for name, s := range m.stages {
+		snap[name] = *s
+	}
+
+	return snap
+}
+
+// Stages returns the names of all stages that have reported metrics in sorted order.
+func (m *Memory) Stages() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.stages))
+	for name := range m.stages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Reset discards all collected metrics.
+func (m *Memory) Reset() {
+	m.mu.Lock()
+	m.stages = nil
+	m.mu.Unlock()
+}
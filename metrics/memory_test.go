@@ -0,0 +1,54 @@
+package metrics_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/curlymon/pipes"
+	"github.com/curlymon/pipes/async"
+	"github.com/curlymon/pipes/metrics"
+)
+
+func TestFilterCountsOnlyKeptItemsOut(t *testing.T) {
+	m := metrics.NewMemory()
+
+	out := pipes.FilterWithMetrics(m, "even", 4, func(i int) bool { return i%2 == 0 }, pipes.Range(0, 0, 10, 1))
+	pipes.Sink(func(int) {}, out)
+
+	s := m.Snapshot()["even"]
+	if s.In != 10 || s.Out != 5 || s.Errors != 0 {
+		t.Fatalf("got in=%d out=%d errors=%d, want in=10 out=5 errors=0", s.In, s.Out, s.Errors)
+	}
+	if s.Cap != 4 {
+		t.Fatalf("got cap=%d, want the cap of the stage's own output 4", s.Cap)
+	}
+}
+
+func TestMapWithErrorCountsErrorsNotOut(t *testing.T) {
+	m := metrics.NewMemory()
+	fail := errors.New("odd")
+
+	out, errs := async.MapWithErrorMetrics(m, "odd", 3, 20, func(i int) (int, error) {
+		if i%2 == 1 {
+			return 0, fail
+		}
+		return i, nil
+	}, pipes.Range(0, 0, 10, 1))
+	pipes.Sink(func(int) {}, out)
+	pipes.Sink(func(error) {}, errs)
+
+	s := m.Snapshot()["odd"]
+	if s.In != 10 || s.Out != 5 || s.Errors != 5 {
+		t.Fatalf("got in=%d out=%d errors=%d, want in=10 out=5 errors=5", s.In, s.Out, s.Errors)
+	}
+}
+
+func TestSinkCountsConsumedItems(t *testing.T) {
+	m := metrics.NewMemory()
+
+	pipes.SinkWithMetrics(m, "sink", func(int) {}, pipes.Range(0, 0, 7, 1))
+
+	if s := m.Snapshot()["sink"]; s.In != 7 || s.Out != 7 {
+		t.Fatalf("got in=%d out=%d, want 7 and 7", s.In, s.Out)
+	}
+}
@@ -0,0 +1,138 @@
+package phttp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/curlymon/pipes"
+)
+
+// Get requests url using client, or http.DefaultClient if nil, decoding the streamed response body
+// as T items whether sent as SSE or NDJSON. The returned channel is closed once the response ends or
+// ctx is cancelled. Items that fail to decode are reported and skipped, while a failed request or
+// error reading the response is reported and ends the stream.
+func Get[T any](ctx context.Context, size int, client *http.Client, url string) (pipes.ChanPull[T], pipes.ChanPull[error]) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	out, err := make(chan T, size), make(chan error, size)
+
+	go getWorker(ctx, client, url, out, err)
+
+	return out, err
+}
+
+func getWorker[T any](ctx context.Context, client *http.Client, url string, out chan<- T, err chan<- error) {
+	defer func() { close(out); close(err) }()
+
+	req, er := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if er != nil {
+		err <- er
+		return
+	}
+	req.Header.Set("Accept", ContentTypeSSE+", "+ContentTypeNDJSON)
+
+	resp, er := client.Do(req)
+	if er != nil {
+		err <- er
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err <- fmt.Errorf("phttp: unexpected status: %s", resp.Status)
+		return
+	}
+
+	decode := func(b []byte) bool {
+		var t T
+		if er := json.Unmarshal(b, &t); er != nil {
+			err <- er
+			return true
+		}
+
+		select {
+		case out <- t:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == ContentTypeSSE {
+		er = readSSE(resp.Body, decode)
+	} else {
+		er = readNDJSON(resp.Body, decode)
+	}
+
+	if er != nil && ctx.Err() == nil {
+		err <- er
+	}
+}
+
+// readNDJSON passes each non blank line of r to decode until r is exhausted or decode returns
+// false.
+func readNDJSON(r io.Reader, decode func([]byte) bool) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 && !decode(line) {
+			return nil
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readSSE passes the data of each event read from r to decode until r is exhausted or decode
+// returns false. Multiple data lines of an event are joined with newlines, other fields are
+// ignored.
+func readSSE(r io.Reader, decode func([]byte) bool) error {
+	var data []byte
+	dispatch := func() bool {
+		if len(data) == 0 {
+			return true
+		}
+
+		ok := decode(bytes.TrimSuffix(data, []byte("\n")))
+		data = data[:0]
+		return ok
+	}
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		line = bytes.TrimRight(line, "\r\n")
+		switch {
+		case len(line) == 0 && err == nil:
+			if !dispatch() {
+				return nil
+			}
+
+		case bytes.HasPrefix(line, []byte("data:")):
+			data = append(data, bytes.TrimPrefix(bytes.TrimPrefix(line, []byte("data:")), []byte(" "))...)
+			data = append(data, '\n')
+		}
+
+		if err == io.EOF {
+			dispatch()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,10 @@
+// Package phttp adapts pipes to HTTP, streaming items into and out of pipelines as newline
+// delimited JSON (NDJSON) or Server-Sent Events (SSE).
+package phttp
+
+const (
+	// ContentTypeNDJSON is the content type of newline delimited JSON streams.
+	ContentTypeNDJSON = "application/x-ndjson"
+	// ContentTypeSSE is the content type of Server-Sent Events streams.
+	ContentTypeSSE = "text/event-stream"
+)
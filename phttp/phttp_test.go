@@ -0,0 +1,100 @@
+package phttp
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/curlymon/pipes"
+)
+
+func TestIngestCountsAcceptedAndRejected(t *testing.T) {
+	out := make(chan int, 10)
+	var errs []error
+	srv := httptest.NewServer(Ingest[int](out, func(err error) { errs = append(errs, err) }))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL, ContentTypeNDJSON, strings.NewReader("1\n2\nnope\n3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var result IngestResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result != (IngestResult{Accepted: 3, Rejected: 1}) {
+		t.Fatalf("got %+v, want 3 accepted 1 rejected", result)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors passed to sink, want 1", len(errs))
+	}
+
+	close(out)
+	if got := pipes.ToSlice(out); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("got %v, want [1 2 3]", got)
+	}
+}
+
+func TestIngestRejectsOtherMethods(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Ingest[int](make(chan int), nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("got %d, want 405", rec.Code)
+	}
+}
+
+func TestStreamToGet(t *testing.T) {
+	for _, accept := range []string{ContentTypeSSE, ContentTypeNDJSON} {
+		t.Run(accept, func(t *testing.T) {
+			srv := httptest.NewServer(Stream(pipes.Range(0, 0, 50, 1), nil))
+			defer srv.Close()
+
+			client := srv.Client()
+			client.Transport = acceptTransport{accept: accept, rt: client.Transport}
+
+			out, errs := Get[int](context.Background(), 0, client, srv.URL)
+			got := pipes.ToSlice(out)
+			for err := range errs {
+				t.Fatal(err)
+			}
+
+			if want := pipes.ToSlice(pipes.Range(0, 0, 50, 1)); !slices.Equal(got, want) {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// acceptTransport overrides the Accept header sent by Get to pick the streaming format.
+type acceptTransport struct {
+	accept string
+	rt     http.RoundTripper
+}
+
+func (a acceptTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("Accept", a.accept)
+	return a.rt.RoundTrip(r)
+}
+
+func TestStreamReportsMarshalErrors(t *testing.T) {
+	var errs []error
+	rec := httptest.NewRecorder()
+	Stream(pipes.FromSlice(0, []float64{1, math.NaN(), 2}), func(err error) { errs = append(errs, err) }).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors, want the NaN reported", len(errs))
+	}
+	if got := rec.Body.String(); got != "1\n2\n" {
+		t.Fatalf("got %q, want %q", got, "1\n2\n")
+	}
+}
@@ -0,0 +1,107 @@
+package phttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/curlymon/pipes"
+	"github.com/curlymon/pipes/codec"
+)
+
+// IngestResult is the JSON response body written by Ingest.
+type IngestResult struct {
+	Accepted int `json:"accepted"`
+	Rejected int `json:"rejected"`
+}
+
+// Ingest returns a handler accepting POSTed NDJSON bodies, including chunked bodies of unbounded
+// length, decoding each line as a T and pushing it onto out. The body is read no faster than out
+// accepts items, so backpressure is applied to the client. Lines that fail to decode are passed to
+// sink, if not nil, and counted as rejected in the IngestResult returned to the client. Ingest never
+// closes out as many requests may feed into it.
+func Ingest[T any](out pipes.ChanPush[T], sink func(error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		var result IngestResult
+		items := codec.DecodeJSONLinesWithErrorSink[T](0, func(err error) {
+			result.Rejected++
+			if sink != nil {
+				sink(err)
+			}
+		}, r.Body)
+
+		for t := range items {
+			select {
+			case out <- t:
+				result.Accepted++
+			case <-r.Context().Done():
+				// release the decoder, the client has gone so there is no one to respond to
+				go items.Drain()
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	})
+}
+
+// Stream returns a handler streaming each T read from in to clients as it arrives, as SSE if the
+// client accepts text/event-stream and NDJSON otherwise. The response ends once in is closed or the
+// client disconnects. Concurrent clients share in with each item sent to only one of them, use
+// pipes.FanOut to broadcast. An item read from in while its client disconnects is lost. Items that
+// fail to marshal are passed to sink, if not nil, and skipped.
+func Stream[T any](in pipes.ChanPull[T], sink func(error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sse := strings.Contains(r.Header.Get("Accept"), ContentTypeSSE)
+		if sse {
+			w.Header().Set("Content-Type", ContentTypeSSE)
+			w.Header().Set("Cache-Control", "no-cache")
+		} else {
+			w.Header().Set("Content-Type", ContentTypeNDJSON)
+		}
+		w.WriteHeader(http.StatusOK)
+
+		rc := http.NewResponseController(w)
+		rc.Flush()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+
+			case t, ok := <-in:
+				if !ok {
+					return
+				}
+
+				b, err := json.Marshal(t)
+				if err != nil {
+					if sink != nil {
+						sink(err)
+					}
+					continue
+				}
+
+				if sse {
+					b = append(append([]byte("data: "), b...), '\n', '\n')
+				} else {
+					b = append(b, '\n')
+				}
+
+				if _, err := w.Write(b); err != nil {
+					return
+				}
+				if err := rc.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+}
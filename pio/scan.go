@@ -0,0 +1,132 @@
+// Package pio provides sources reading from an io.Reader and sinks writing to an io.Writer.
+package pio
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"github.com/curlymon/pipes"
+)
+
+// Scanner configures how an io.Reader is split into tokens.
+type Scanner struct {
+	// Split splits the input into tokens. Defaults to bufio.ScanLines.
+	Split bufio.SplitFunc
+	// MaxTokenSize is the largest token that may be read. Defaults to bufio.MaxScanTokenSize.
+	MaxTokenSize int
+}
+
+// Lines is a Scanner splitting input into lines with the line endings stripped.
+var Lines = Scanner{Split: bufio.ScanLines}
+
+// Words is a Scanner splitting input into space separated words.
+var Words = Scanner{Split: bufio.ScanWords}
+
+func (s Scanner) scanner(r io.Reader) *bufio.Scanner {
+	sc := bufio.NewScanner(r)
+	if s.Split != nil {
+		sc.Split(s.Split)
+	}
+	if s.MaxTokenSize > 0 {
+		initial := 4096
+		if s.MaxTokenSize < initial {
+			initial = s.MaxTokenSize
+		}
+		sc.Buffer(make([]byte, 0, initial), s.MaxTokenSize)
+	}
+
+	return sc
+}
+
+// SplitOn returns a bufio.SplitFunc splitting input on each occurrence of delim. The delimiter is
+// not included in the tokens and a final token without a trailing delimiter is still returned.
+func SplitOn(delim []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+
+		if i := bytes.Index(data, delim); i >= 0 {
+			return i + len(delim), data[:i], nil
+		}
+
+		if atEOF {
+			return len(data), data, nil
+		}
+
+		return 0, nil, nil
+	}
+}
+
+// ScanWithError pushes each token read from r onto the returned channel as a string, closing it once r is
+// exhausted. Any error encountered reading r is pushed onto the returned error channel and ends the
+// scan.
+func ScanWithError(size int, s Scanner, r io.Reader) (pipes.ChanPull[string], pipes.ChanPull[error]) {
+	out, err := make(chan string, size), make(chan error, size)
+
+	go scanWorker(s.scanner(r), (*bufio.Scanner).Text, out, err)
+
+	return out, err
+}
+
+// ScanWithErrorSink pushes each token read from r onto the returned channel as a string, closing it
+// once r is exhausted. Any error encountered reading r is passed to sink and ends the scan.
+func ScanWithErrorSink(size int, s Scanner, sink func(error), r io.Reader) pipes.ChanPull[string] {
+	out := make(chan string, size)
+
+	go scanWithErrorSinkWorker(s.scanner(r), (*bufio.Scanner).Text, sink, out)
+
+	return out
+}
+
+// ScanBytesWithError pushes each token read from r onto the returned channel as a []byte, closing it once r
+// is exhausted. Any error encountered reading r is pushed onto the returned error channel and ends
+// the scan. Each token is a copy and safe to retain.
+func ScanBytesWithError(size int, s Scanner, r io.Reader) (pipes.ChanPull[[]byte], pipes.ChanPull[error]) {
+	out, err := make(chan []byte, size), make(chan error, size)
+
+	go scanWorker(s.scanner(r), scanBytes, out, err)
+
+	return out, err
+}
+
+// ScanBytesWithErrorSink pushes each token read from r onto the returned channel as a []byte,
+// closing it once r is exhausted. Any error encountered reading r is passed to sink and ends the
+// scan. Each token is a copy and safe to retain.
+func ScanBytesWithErrorSink(size int, s Scanner, sink func(error), r io.Reader) pipes.ChanPull[[]byte] {
+	out := make(chan []byte, size)
+
+	go scanWithErrorSinkWorker(s.scanner(r), scanBytes, sink, out)
+
+	return out
+}
+
+// scanBytes copies the current token as the scanner reuses its buffer between calls to Scan.
+func scanBytes(sc *bufio.Scanner) []byte {
+	return append([]byte(nil), sc.Bytes()...)
+}
+
+func scanWorker[T any](sc *bufio.Scanner, token func(*bufio.Scanner) T, out chan<- T, err chan<- error) {
+	defer func() { close(out); close(err) }()
+
+	for sc.Scan() {
+		out <- token(sc)
+	}
+
+	if er := sc.Err(); er != nil {
+		err <- er
+	}
+}
+
+func scanWithErrorSinkWorker[T any](sc *bufio.Scanner, token func(*bufio.Scanner) T, sink func(error), out chan<- T) {
+	defer close(out)
+
+	for sc.Scan() {
+		out <- token(sc)
+	}
+
+	if err := sc.Err(); err != nil {
+		sink(err)
+	}
+}
@@ -0,0 +1,98 @@
+package pio
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/curlymon/pipes"
+)
+
+var errRead = errors.New("read failed")
+
+// failingReader returns its content followed by errRead rather than io.EOF.
+func failingReader(content string) io.Reader {
+	return io.MultiReader(strings.NewReader(content), readerFunc(func([]byte) (int, error) { return 0, errRead }))
+}
+
+type readerFunc func([]byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
+
+func TestScanWithError(t *testing.T) {
+	for name, tc := range map[string]struct {
+		s    Scanner
+		in   string
+		want []string
+	}{
+		"lines":      {Lines, "a\nb\r\n\nc", []string{"a", "b", "", "c"}},
+		"words":      {Words, " a  b\tc\n", []string{"a", "b", "c"}},
+		"split on":   {Scanner{Split: SplitOn([]byte("||"))}, "a||b||c", []string{"a", "b", "c"}},
+		"empty":      {Lines, "", nil},
+		"no newline": {Lines, "a", []string{"a"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			out, errs := ScanWithError(0, tc.s, strings.NewReader(tc.in))
+			got := pipes.ToSlice(out)
+			for err := range errs {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestScanWithErrorReportsReadError(t *testing.T) {
+	// buffered so that the error does not block closing out
+	out, errs := ScanWithError(1, Lines, failingReader("a\nb\n"))
+
+	if got := pipes.ToSlice(out); !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("got %q, want [a b]", got)
+	}
+	if got := pipes.ToSlice(errs); len(got) != 1 || !errors.Is(got[0], errRead) {
+		t.Fatalf("got %v, want %v", got, errRead)
+	}
+}
+
+func TestScanWithErrorTokenTooLong(t *testing.T) {
+	out, errs := ScanWithError(1, Scanner{MaxTokenSize: 4}, strings.NewReader("ab\nabcdef\n"))
+
+	if got := pipes.ToSlice(out); !slices.Equal(got, []string{"ab"}) {
+		t.Fatalf("got %q, want [ab]", got)
+	}
+	if got := pipes.ToSlice(errs); len(got) != 1 {
+		t.Fatalf("got %v, want a token too long error", got)
+	}
+}
+
+func TestScanWithErrorSink(t *testing.T) {
+	var errs []error
+	out := ScanWithErrorSink(0, Lines, func(err error) { errs = append(errs, err) }, failingReader("a\nb"))
+
+	if got := pipes.ToSlice(out); !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("got %q, want [a b]", got)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], errRead) {
+		t.Fatalf("got %v, want %v", errs, errRead)
+	}
+}
+
+func TestScanBytesCopiesTokens(t *testing.T) {
+	var errs []error
+	got := pipes.ToSlice(ScanBytesWithErrorSink(10, Lines, func(err error) { errs = append(errs, err) }, strings.NewReader("aa\nbb\ncc\n")))
+
+	if len(errs) != 0 || len(got) != 3 || string(got[0]) != "aa" || string(got[1]) != "bb" || string(got[2]) != "cc" {
+		t.Fatalf("got %q %v, want [aa bb cc]", got, errs)
+	}
+
+	out, errc := ScanBytesWithError(0, Words, strings.NewReader("x y"))
+	go errc.Drain()
+	if got := pipes.ToSlice(out); len(got) != 2 || string(got[0]) != "x" || string(got[1]) != "y" {
+		t.Fatalf("got %q, want [x y]", got)
+	}
+}
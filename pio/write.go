@@ -0,0 +1,123 @@
+package pio
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/curlymon/pipes"
+)
+
+// FormatLine formats t using its default format followed by a newline.
+func FormatLine[T any](t T) []byte {
+	return []byte(fmt.Sprintln(t))
+}
+
+// WriteWithError writes each T read from in to w as formatted by format in the background, closing the
+// returned error channel once in is closed and everything written has been flushed. Writes are
+// buffered and flushed every flush interval, or after every item if flush is 0, and once more when
+// in is closed. Any errors writing are pushed onto the returned error channel.
+func WriteWithError[T any](size int, flush time.Duration, format func(T) []byte, w io.Writer, in <-chan T) pipes.ChanPull[error] {
+	err := make(chan error, size)
+
+	go writeWithErrorWorker(flush, format, w, in, err)
+
+	return err
+}
+
+func writeWithErrorWorker[T any](flush time.Duration, format func(T) []byte, w io.Writer, in <-chan T, err chan<- error) {
+	defer close(err)
+
+	writeWorker(flush, format, w, func(er error) { err <- er }, in)
+}
+
+// WriteWithErrorSink is a blocking operation that writes each T read from in to w as formatted by
+// format, returning once in is closed. Writes are buffered and flushed every flush interval, or
+// after every item if flush is 0, and once more when in is closed. Any errors writing are passed to
+// sink.
+func WriteWithErrorSink[T any](flush time.Duration, format func(T) []byte, sink func(error), w io.Writer, in <-chan T) {
+	writeWorker(flush, format, w, sink, in)
+}
+
+func writeWorker[T any](flush time.Duration, format func(T) []byte, w io.Writer, sink func(error), in <-chan T) {
+	bw := &retryWriter{w: w}
+	defer func() {
+		if err := bw.Flush(); err != nil {
+			sink(err)
+		}
+	}()
+
+	// a nil channel is never ready so flushing on a tick is disabled without a flush interval
+	var tick <-chan time.Time
+	if flush > 0 {
+		ticker := time.NewTicker(flush)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case t, ok := <-in:
+			if !ok {
+				return
+			}
+
+			bw.Write(format(t))
+			if flush <= 0 || bw.Full() {
+				if err := bw.Flush(); err != nil {
+					sink(err)
+				}
+			}
+
+		case <-tick:
+			if err := bw.Flush(); err != nil {
+				sink(err)
+			}
+		}
+	}
+}
+
+const (
+	// writeBufferSize is the number of buffered bytes that triggers a flush between intervals.
+	writeBufferSize = 4096
+	// maxPendingSize is the number of unwritten bytes kept for retry after failed flushes before
+	// they are discarded.
+	maxPendingSize = 1 << 20
+)
+
+// retryWriter buffers writes to w. Unlike a bufio.Writer a failed flush is not sticky, the bytes w
+// did not accept are kept and retried on the next flush rather than thrown away, up to
+// maxPendingSize.
+type retryWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+func (r *retryWriter) Write(p []byte) {
+	r.buf = append(r.buf, p...)
+}
+
+// Full reports whether enough has been buffered to be worth flushing.
+func (r *retryWriter) Full() bool {
+	return len(r.buf) >= writeBufferSize
+}
+
+// Flush writes everything buffered to w, keeping whatever w did not accept on error.
+func (r *retryWriter) Flush() error {
+	if len(r.buf) == 0 {
+		return nil
+	}
+
+	n, err := r.w.Write(r.buf)
+	if err == nil && n < len(r.buf) {
+		err = io.ErrShortWrite
+	}
+	r.buf = r.buf[:copy(r.buf, r.buf[n:])]
+
+	if err != nil && len(r.buf) > maxPendingSize {
+		err = fmt.Errorf("pio: discarded %d unwritten bytes: %w", len(r.buf), err)
+		r.buf = r.buf[:0]
+	}
+
+	return err
+}
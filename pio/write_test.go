@@ -0,0 +1,52 @@
+package pio
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/curlymon/pipes"
+)
+
+// flakyWriter fails its first fail writes after accepting half of what it is given.
+type flakyWriter struct {
+	bytes.Buffer
+	fail int
+}
+
+func (f *flakyWriter) Write(p []byte) (int, error) {
+	if f.fail > 0 {
+		f.fail--
+		n, _ := f.Buffer.Write(p[:len(p)/2])
+		return n, errors.New("flaky")
+	}
+
+	return f.Buffer.Write(p)
+}
+
+func TestWriteRetriesUnwrittenBytes(t *testing.T) {
+	w := &flakyWriter{fail: 2}
+
+	var errs []error
+	WriteWithErrorSink(0, FormatLine[int], func(err error) { errs = append(errs, err) }, w, pipes.Range(0, 1, 5, 1))
+
+	if got, want := w.String(), "1\n2\n3\n4\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors, want 2", len(errs))
+	}
+}
+
+func TestWriteFlushesBeforeClosingErrors(t *testing.T) {
+	var buf bytes.Buffer
+
+	errs := WriteWithError(0, 0, FormatLine[string], &buf, pipes.FromSlice(0, []string{"a", "b"}))
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); got != "a\nb\n" {
+		t.Fatalf("got %q, want %q", got, "a\nb\n")
+	}
+}
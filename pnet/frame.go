@@ -0,0 +1,29 @@
+// Package pnet connects pipelines across processes over a net.Conn.
+//
+// A Sender reads items from a channel and writes them as framed, encoded values to a connection,
+// while a Receiver exposes the items read from connections accepted by a listener on a channel.
+// Every item is acknowledged by the Receiver once it has been pushed onto its output, and a Sender
+// stops reading its input while its window of unacknowledged items is full, so backpressure
+// carries across the connection. Should the connection fail the Sender redials and resends every
+// unacknowledged item, the Receiver discarding any it has already delivered. Once the Sender's
+// input is closed and every item acknowledged the close is propagated, closing the Receiver's
+// output.
+//
+// Each Receiver expects items from a single Sender at a time.
+package pnet
+
+type frameKind uint8
+
+const (
+	frameItem     frameKind = iota + 1 // Sender -> Receiver: an item
+	frameAck                           // Receiver -> Sender: all items up to Seq are delivered
+	frameClose                         // Sender -> Receiver: the stream is complete
+	frameCloseAck                      // Receiver -> Sender: the close has been propagated
+)
+
+// frame is the unit written to a connection in both directions. Item is only set for frameItem.
+type frame[T any] struct {
+	Kind frameKind
+	Seq  uint64
+	Item T
+}
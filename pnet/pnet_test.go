@@ -0,0 +1,230 @@
+package pnet
+
+import (
+	"errors"
+	"net"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/curlymon/pipes"
+	"github.com/curlymon/pipes/codec"
+)
+
+// collect reads every item until out is closed, failing the test if that takes too long.
+func collect[T any](t *testing.T, out <-chan T) []T {
+	t.Helper()
+
+	done := make(chan []T, 1)
+	go func() { done <- pipes.ToSlice(out) }()
+
+	select {
+	case got := <-done:
+		return got
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the stream to close")
+		return nil
+	}
+}
+
+func drainErrors(t *testing.T, errs <-chan error) []error {
+	t.Helper()
+
+	done := make(chan []error, 1)
+	go func() { done <- pipes.ToSlice(errs) }()
+
+	select {
+	case got := <-done:
+		return got
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the error channel to close")
+		return nil
+	}
+}
+
+func TestSendReceiveOverPipe(t *testing.T) {
+	client, server := net.Pipe()
+
+	dialled := false
+	sendErrs := Send(0, Sender{
+		Dial: func() (net.Conn, error) {
+			if dialled {
+				return nil, errors.New("already dialled")
+			}
+			dialled = true
+			return client, nil
+		},
+		Window:  4,
+		Retries: 1,
+	}, pipes.Range(0, 0, 100, 1))
+
+	out, recvErrs := ReceiveConn[int](0, Receiver{}, server)
+	go func() {
+		for err := range recvErrs {
+			t.Errorf("receive: %v", err)
+		}
+	}()
+
+	got := collect(t, out)
+	if want := pipes.ToSlice(pipes.Range(0, 0, 100, 1)); !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if errs := drainErrors(t, sendErrs); len(errs) != 0 {
+		t.Fatalf("send reported %v after a clean close", errs)
+	}
+}
+
+// dropConn closes itself after a number of writes, simulating a connection failing mid stream.
+type dropConn struct {
+	net.Conn
+	writes atomic.Int32
+	after  int32
+}
+
+func (c *dropConn) Write(p []byte) (int, error) {
+	if c.writes.Add(1) > c.after {
+		c.Conn.Close()
+		return 0, net.ErrClosed
+	}
+
+	return c.Conn.Write(p)
+}
+
+func TestSendReconnectsAndResendsUnacked(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("loopback unavailable:", err)
+	}
+
+	var dials atomic.Int32
+	sendErrs := Send(0, Sender{
+		Dial: func() (net.Conn, error) {
+			conn, err := net.Dial("tcp", ln.Addr().String())
+			if err != nil {
+				return nil, err
+			}
+			// the first connection fails part way through, later ones are healthy
+			if dials.Add(1) == 1 {
+				return &dropConn{Conn: conn, after: 20}, nil
+			}
+			return conn, nil
+		},
+		Window:  8,
+		Backoff: time.Millisecond,
+	}, pipes.Range(0, 0, 200, 1))
+
+	var errs []error
+	errsDone := make(chan struct{})
+	go func() {
+		defer close(errsDone)
+		errs = pipes.ToSlice(sendErrs)
+	}()
+
+	out, recvErrs := Receive[int](0, Receiver{}, ln)
+	go pipes.ChanPull[error](recvErrs).Drain()
+
+	got := collect(t, out)
+	if want := pipes.ToSlice(pipes.Range(0, 0, 200, 1)); !slices.Equal(got, want) {
+		t.Fatalf("got %d items %v, want each of 0..199 exactly once in order", len(got), got)
+	}
+
+	select {
+	case <-errsDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sender did not finish after the close was acknowledged")
+	}
+
+	if dials.Load() < 2 {
+		t.Fatalf("dialled %d times, want a reconnect", dials.Load())
+	}
+	if len(errs) == 0 {
+		t.Fatal("the connection failure was not reported")
+	}
+}
+
+func TestSendStopsAfterCloseAcknowledged(t *testing.T) {
+	// the Receiver closing its end right after the close ack must not be mistaken for a failure,
+	// with Retries 0 the Sender would otherwise redial the closed listener forever
+	for i := 0; i < 20; i++ {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Skip("loopback unavailable:", err)
+		}
+		addr := ln.Addr().String()
+
+		sendErrs := Send(0, Sender{
+			Dial:    func() (net.Conn, error) { return net.Dial("tcp", addr) },
+			Backoff: time.Millisecond,
+		}, pipes.Range(0, 0, 10, 1))
+
+		out, recvErrs := Receive[int](0, Receiver{}, ln)
+		go pipes.ChanPull[error](recvErrs).Drain()
+
+		if got := collect(t, out); len(got) != 10 {
+			t.Fatalf("got %v, want 10 items", got)
+		}
+		if errs := drainErrors(t, sendErrs); len(errs) != 0 {
+			t.Fatalf("send reported %v after a clean close", errs)
+		}
+	}
+}
+
+func TestReceiveTracksConnectionsWhileDeliveryBlocks(t *testing.T) {
+	out := make(chan int)
+	rc := &receiver[int]{out: out, ln: &connListener{done: make(chan struct{})}, conns: make(map[net.Conn]struct{})}
+
+	// nobody reads out so this delivery blocks
+	go rc.deliver(1, 1)
+	time.Sleep(10 * time.Millisecond)
+
+	client, server := net.Pipe()
+	defer client.Close()
+
+	tracked := make(chan bool, 1)
+	go func() { tracked <- rc.track(server) }()
+
+	select {
+	case ok := <-tracked:
+		if !ok {
+			t.Fatal("connection rejected by an open receiver")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("tracking a reconnect was held up by a blocked delivery")
+	}
+
+	if got := <-out; got != 1 {
+		t.Fatalf("got %d, want 1", got)
+	}
+
+	rc.close(nil)
+	if _, ok := <-out; ok {
+		t.Fatal("out not closed")
+	}
+}
+
+func TestReadAcksStopsAfterCloseAck(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	c := &senderConn[int]{conn: client, notify: make(chan struct{}, 1), failed: make(chan error, 1)}
+	go c.readAcks(codec.Gob.NewDecoder(client))
+
+	go func() {
+		codec.Gob.NewEncoder(server).Encode(frame[int]{Kind: frameCloseAck})
+		server.Close()
+	}()
+
+	<-c.notify
+	if !c.closed.Load() {
+		t.Fatal("close ack not recorded")
+	}
+
+	// the Receiver hanging up after the close ack must not be reported as a failure
+	select {
+	case err := <-c.failed:
+		t.Fatalf("graceful close reported as failure: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
@@ -0,0 +1,208 @@
+package pnet
+
+import (
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/curlymon/pipes"
+	"github.com/curlymon/pipes/codec"
+)
+
+// Receiver configures Receive.
+type Receiver struct {
+	// Codec decodes frames from the connection. Defaults to codec.Gob.
+	Codec codec.Codec
+}
+
+// Receive pushes each T sent by a Sender over connections accepted from ln onto the returned
+// channel. Connections are served concurrently so that a reconnecting Sender is not held up by a
+// stale connection, items already delivered are discarded. Once the Sender propagates its close the
+// returned channel and ln are closed. Connection errors are pushed onto the returned error channel.
+func Receive[T any](size int, r Receiver, ln net.Listener) (pipes.ChanPull[T], pipes.ChanPull[error]) {
+	if r.Codec == nil {
+		r.Codec = codec.Gob
+	}
+
+	out, err := make(chan T, size), make(chan error, size)
+
+	go receiveWorker(r, ln, out, err)
+
+	return out, err
+}
+
+// ReceiveConn behaves as Receive for a single established connection such as one end of a
+// net.Pipe. A Sender cannot reconnect to it.
+func ReceiveConn[T any](size int, r Receiver, conn net.Conn) (pipes.ChanPull[T], pipes.ChanPull[error]) {
+	return Receive[T](size, r, &connListener{conn: conn, done: make(chan struct{})})
+}
+
+// receiver holds the state shared between the connections of a Receive.
+type receiver[T any] struct {
+	mu     sync.Mutex // guards closed, ln and conns, never held while blocked on out
+	closed bool
+	ln     net.Listener
+	conns  map[net.Conn]struct{}
+
+	delivering sync.Mutex // serializes pushes onto out along with closing it
+	delivered  uint64     // highest sequence pushed onto out
+	out        chan<- T
+}
+
+func receiveWorker[T any](r Receiver, ln net.Listener, out chan<- T, err chan<- error) {
+	rc := &receiver[T]{out: out, ln: ln, conns: make(map[net.Conn]struct{})}
+	wg := &sync.WaitGroup{}
+
+	defer func() {
+		rc.close(nil)
+		wg.Wait()
+		close(err)
+	}()
+
+	for {
+		conn, er := ln.Accept()
+		if er != nil {
+			if !rc.isClosed() && !errors.Is(er, net.ErrClosed) {
+				err <- er
+			}
+			return
+		}
+
+		if !rc.track(conn) {
+			conn.Close()
+			return
+		}
+
+		wg.Add(1)
+		go rc.serve(wg, r.Codec, conn, err)
+	}
+}
+
+func (rc *receiver[T]) serve(wg *sync.WaitGroup, c codec.Codec, conn net.Conn, err chan<- error) {
+	defer wg.Done()
+	defer rc.untrack(conn)
+
+	enc, dec := c.NewEncoder(conn), c.NewDecoder(conn)
+	for {
+		var f frame[T]
+		if er := dec.Decode(&f); er != nil {
+			if !rc.isClosed() {
+				err <- er
+			}
+			return
+		}
+
+		switch f.Kind {
+		case frameItem:
+			seq := rc.deliver(f.Seq, f.Item)
+			if er := enc.Encode(frame[T]{Kind: frameAck, Seq: seq}); er != nil {
+				err <- er
+				return
+			}
+
+		case frameClose:
+			rc.close(conn)
+			// the Sender redials and closes again should the acknowledgement be lost
+			enc.Encode(frame[T]{Kind: frameCloseAck, Seq: f.Seq})
+			return
+		}
+	}
+}
+
+// deliver pushes t onto out unless it was already delivered, returning the highest sequence
+// delivered so far to acknowledge.
+//
+// Only delivering is held while blocked pushing onto out, so a slow consumer does not hold up
+// accepting or tracking a reconnecting Sender's connection.
+func (rc *receiver[T]) deliver(seq uint64, t T) uint64 {
+	rc.delivering.Lock()
+	defer rc.delivering.Unlock()
+
+	if !rc.isClosed() && seq == rc.delivered+1 {
+		rc.out <- t
+		rc.delivered = seq
+	}
+
+	return rc.delivered
+}
+
+// close closes out, the listener and every connection other than except once. The listener and
+// connections are closed first, out once any push in progress has completed.
+func (rc *receiver[T]) close(except net.Conn) {
+	rc.mu.Lock()
+	if rc.closed {
+		rc.mu.Unlock()
+		return
+	}
+
+	rc.closed = true
+	rc.ln.Close()
+	for conn := range rc.conns {
+		if conn != except {
+			conn.Close()
+		}
+	}
+	rc.mu.Unlock()
+
+	rc.delivering.Lock()
+	close(rc.out)
+	rc.delivering.Unlock()
+}
+
+// track registers conn as open, returning false if the receiver has already been closed.
+func (rc *receiver[T]) track(conn net.Conn) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.closed {
+		return false
+	}
+
+	rc.conns[conn] = struct{}{}
+
+	return true
+}
+
+// untrack closes and deregisters conn.
+func (rc *receiver[T]) untrack(conn net.Conn) {
+	rc.mu.Lock()
+	delete(rc.conns, conn)
+	rc.mu.Unlock()
+
+	conn.Close()
+}
+
+func (rc *receiver[T]) isClosed() bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	return rc.closed
+}
+
+// connListener is a net.Listener accepting a single established connection.
+type connListener struct {
+	once sync.Once
+	conn net.Conn
+	done chan struct{}
+	shut sync.Once
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	var conn net.Conn
+	l.once.Do(func() { conn = l.conn })
+	if conn != nil {
+		return conn, nil
+	}
+
+	<-l.done
+	return nil, net.ErrClosed
+}
+
+func (l *connListener) Close() error {
+	l.shut.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
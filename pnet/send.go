@@ -0,0 +1,223 @@
+package pnet
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/curlymon/pipes"
+	"github.com/curlymon/pipes/codec"
+)
+
+// ErrRetriesExhausted is reported when a Sender gives up reconnecting.
+var ErrRetriesExhausted = errors.New("pnet: retries exhausted")
+
+// Sender configures Send.
+type Sender struct {
+	// Dial opens a connection to the Receiver. It is called again to reconnect after a failure.
+	Dial func() (net.Conn, error)
+	// Codec encodes frames onto the connection. Defaults to codec.Gob.
+	Codec codec.Codec
+	// Window is the maximum number of unacknowledged items in flight. Defaults to 64.
+	Window int
+	// Backoff is the delay between reconnection attempts. Defaults to 100ms.
+	Backoff time.Duration
+	// Retries is the number of consecutive failed dials before giving up, 0 retries forever.
+	Retries int
+}
+
+// Send writes each T read from in to connections opened by s.Dial until in is closed and every item
+// has been acknowledged, after which the close is propagated to the Receiver. Connection failures
+// are pushed onto the returned error channel as they happen and the connection redialled. If the
+// retries are exhausted ErrRetriesExhausted is reported and the remainder of in is drained in the
+// background.
+func Send[T any](size int, s Sender, in <-chan T) pipes.ChanPull[error] {
+	if s.Codec == nil {
+		s.Codec = codec.Gob
+	}
+	if s.Window < 1 {
+		s.Window = 64
+	}
+	if s.Backoff <= 0 {
+		s.Backoff = 100 * time.Millisecond
+	}
+
+	err := make(chan error, size)
+
+	go sendWorker(s, in, err)
+
+	return err
+}
+
+type unacked[T any] struct {
+	seq  uint64
+	item T
+}
+
+func sendWorker[T any](s Sender, in <-chan T, err chan<- error) {
+	defer close(err)
+
+	var (
+		pending []unacked[T]
+		seq     uint64
+		closing bool
+		c       *senderConn[T]
+	)
+
+	defer func() {
+		if c != nil {
+			c.close()
+		}
+	}()
+
+	for {
+		if c == nil {
+			if c = dialSender[T](s, err); c == nil {
+				go pipes.ChanPull[T](in).Drain()
+				return
+			}
+
+			// anything unacknowledged may have been lost with the previous connection
+			for _, u := range pending {
+				if er := c.send(frame[T]{Kind: frameItem, Seq: u.seq, Item: u.item}); er != nil {
+					break
+				}
+			}
+			if closing && len(pending) == 0 {
+				c.send(frame[T]{Kind: frameClose, Seq: seq})
+			}
+		}
+
+		// a nil channel is never ready, stop reading in while the window is full or once closed
+		read := in
+		if closing || len(pending) >= s.Window {
+			read = nil
+		}
+
+		select {
+		case t, ok := <-read:
+			if !ok {
+				closing, in = true, nil
+				if len(pending) == 0 {
+					c.send(frame[T]{Kind: frameClose, Seq: seq})
+				}
+				continue
+			}
+
+			seq++
+			pending = append(pending, unacked[T]{seq: seq, item: t})
+			c.send(frame[T]{Kind: frameItem, Seq: seq, Item: t})
+
+		case <-c.notify:
+			if c.closed.Load() {
+				return
+			}
+
+			acked, i := c.acked.Load(), 0
+			for i < len(pending) && pending[i].seq <= acked {
+				i++
+			}
+			pending = pending[i:]
+
+			if closing && i > 0 && len(pending) == 0 {
+				c.send(frame[T]{Kind: frameClose, Seq: seq})
+			}
+
+		case er := <-c.failed:
+			// the Receiver closing the connection after acknowledging the close is not a failure,
+			// it may race the notification of the close itself
+			if c.closed.Load() {
+				return
+			}
+
+			err <- er
+			c.close()
+			c = nil
+		}
+	}
+}
+
+// senderConn is a single connection of a Sender along with the goroutine reading its acks.
+type senderConn[T any] struct {
+	conn    net.Conn
+	enc     codec.Encoder
+	acked   atomic.Uint64 // highest sequence acknowledged
+	closed  atomic.Bool   // the close has been acknowledged
+	notify  chan struct{} // signalled after acked or closed change
+	failed  chan error    // receives the first error on the connection
+	failure sync.Once
+}
+
+func dialSender[T any](s Sender, err chan<- error) *senderConn[T] {
+	for attempt := 1; ; attempt++ {
+		conn, er := s.Dial()
+		if er == nil {
+			c := &senderConn[T]{
+				conn:   conn,
+				enc:    s.Codec.NewEncoder(conn),
+				notify: make(chan struct{}, 1),
+				failed: make(chan error, 1),
+			}
+			go c.readAcks(s.Codec.NewDecoder(conn))
+			return c
+		}
+
+		err <- er
+		if s.Retries > 0 && attempt >= s.Retries {
+			err <- ErrRetriesExhausted
+			return nil
+		}
+
+		time.Sleep(s.Backoff)
+	}
+}
+
+// send writes f to the connection. Failures are reported through c.failed so that they are handled
+// in one place by the Sender, the error is only returned to allow cutting a batch of sends short.
+func (c *senderConn[T]) send(f frame[T]) error {
+	err := c.enc.Encode(f)
+	if err != nil {
+		c.fail(err)
+	}
+
+	return err
+}
+
+func (c *senderConn[T]) fail(err error) {
+	c.failure.Do(func() { c.failed <- err })
+}
+
+func (c *senderConn[T]) close() {
+	c.conn.Close()
+}
+
+func (c *senderConn[T]) readAcks(dec codec.Decoder) {
+	for {
+		var f frame[T]
+		if err := dec.Decode(&f); err != nil {
+			c.fail(err)
+			return
+		}
+
+		switch f.Kind {
+		case frameAck:
+			c.acked.Store(f.Seq)
+		case frameCloseAck:
+			c.closed.Store(true)
+		default:
+			continue
+		}
+
+		select {
+		case c.notify <- struct{}{}:
+		default: // a notification is already pending
+		}
+
+		// nothing follows the close acknowledgement but the Receiver closing the connection
+		if f.Kind == frameCloseAck {
+			return
+		}
+	}
+}
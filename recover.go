@@ -0,0 +1,125 @@
+package pipes
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrStop may be returned, or wrapped, by the function passed to any WithError or WithErrorSink
+// stage to request the stage shut down. The error is reported as usual after which the stage closes
+// its outputs and discards the remainder of its input in the background so that upstream stages are
+// not left blocked.
+var ErrStop = errors.New("pipes: stage stopped")
+
+// RecoverPolicy decides what a stage does after recovering from a panic.
+type RecoverPolicy int
+
+const (
+	// RecoverSkip reports the panic as an error and moves on to the next item.
+	RecoverSkip RecoverPolicy = iota
+	// RecoverStop reports the panic as an error and then shuts the stage down, see ErrStop.
+	RecoverStop
+)
+
+// PanicError is the error reported in place of a recovered panic.
+type PanicError struct {
+	Value  any
+	Stack  []byte
+	Policy RecoverPolicy
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("pipes: recovered panic: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Is reports the PanicError as ErrStop when recovered under the RecoverStop policy.
+func (e *PanicError) Is(target error) bool {
+	return target == ErrStop && e.Policy == RecoverStop
+}
+
+// Unwrap returns the panic value if it was an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// RecoverMap converts panics raised by mp into a *PanicError handled according to policy. The
+// returned function can be passed to MapWithError, MapWithErrorSink or their async counterparts.
+func RecoverMap[T any, N any](policy RecoverPolicy, mp func(T) N) func(T) (N, error) {
+	return RecoverMapWithError(policy, func(t T) (N, error) { return mp(t), nil })
+}
+
+// RecoverMapWithError converts panics raised by mp into a *PanicError handled according to policy.
+// The returned function can be passed to MapWithError, MapWithErrorSink or their async
+// counterparts.
+func RecoverMapWithError[T any, N any](policy RecoverPolicy, mp func(T) (N, error)) func(T) (N, error) {
+	return func(t T) (n N, err error) {
+		defer recoverPanic(policy, &err)
+		return mp(t)
+	}
+}
+
+// RecoverFilter converts panics raised by filter into a *PanicError handled according to policy.
+// The returned function can be passed to FilterWithError or FilterWithErrorSink.
+func RecoverFilter[T any](policy RecoverPolicy, filter func(T) bool) func(T) (bool, error) {
+	return RecoverMapWithError(policy, func(t T) (bool, error) { return filter(t), nil })
+}
+
+// RecoverFilterWithError converts panics raised by filter into a *PanicError handled according to
+// policy. The returned function can be passed to FilterWithError or FilterWithErrorSink.
+func RecoverFilterWithError[T any](policy RecoverPolicy, filter func(T) (bool, error)) func(T) (bool, error) {
+	return RecoverMapWithError(policy, filter)
+}
+
+// RecoverSink converts panics raised by sink into a *PanicError handled according to policy. The
+// returned function can be passed to SinkWithError, SinkWithErrorSink, TapWithError or
+// TapWithErrorSink.
+func RecoverSink[T any](policy RecoverPolicy, sink func(T)) func(T) error {
+	return RecoverSinkWithError(policy, func(t T) error { sink(t); return nil })
+}
+
+// RecoverSinkWithError converts panics raised by sink into a *PanicError handled according to
+// policy. The returned function can be passed to SinkWithError, SinkWithErrorSink, TapWithError or
+// TapWithErrorSink.
+func RecoverSinkWithError[T any](policy RecoverPolicy, sink func(T) error) func(T) error {
+	return func(t T) (err error) {
+		defer recoverPanic(policy, &err)
+		return sink(t)
+	}
+}
+
+// RecoverRoute converts panics raised by a Router compare or Distribute choose function into a
+// *PanicError passed to sink, routing the item to fallback instead. Routing stages have no error
+// output to shut down on so the RecoverSkip policy is always applied.
+func RecoverRoute[T any, N any](route func(T) N, fallback N, sink func(error)) func(T) N {
+	return func(t T) (n N) {
+		defer func() {
+			if v := recover(); v != nil {
+				sink(&PanicError{Value: v, Stack: debug.Stack(), Policy: RecoverSkip})
+				n = fallback
+			}
+		}()
+		return route(t)
+	}
+}
+
+// recoverPanic must be deferred directly, it recovers any panic in flight and stores it in err as a
+// *PanicError.
+func recoverPanic(policy RecoverPolicy, err *error) {
+	if v := recover(); v != nil {
+		*err = &PanicError{Value: v, Stack: debug.Stack(), Policy: policy}
+	}
+}
+
+// stopping reports whether err requests the stage to stop, see ErrStop. When it does the remainder
+// of in is drained in the background to release upstream stages.
+func stopping[T any](err error, in <-chan T) bool {
+	if !errors.Is(err, ErrStop) {
+		return false
+	}
+
+	go ChanPull[T](in).Drain()
+
+	return true
+}
@@ -0,0 +1,121 @@
+package pipes
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"testing"
+)
+
+func panicAt2(i int) int {
+	if i == 2 {
+		panic("boom")
+	}
+	return i
+}
+
+func TestRecoverPolicies(t *testing.T) {
+	stages := []struct {
+		name string
+		run  func(policy RecoverPolicy, sink func(error), in <-chan int) []int
+	}{
+		{"map", func(policy RecoverPolicy, sink func(error), in <-chan int) []int {
+			return ToSlice(MapWithErrorSink(0, RecoverMap(policy, panicAt2), sink, in))
+		}},
+		{"filter", func(policy RecoverPolicy, sink func(error), in <-chan int) []int {
+			filter := RecoverFilter(policy, func(i int) bool { panicAt2(i); return true })
+			return ToSlice(FilterWithErrorSink(0, filter, sink, in))
+		}},
+		{"sink", func(policy RecoverPolicy, sink func(error), in <-chan int) []int {
+			var got []int
+			SinkWithErrorSink(RecoverSink(policy, func(i int) { got = append(got, panicAt2(i)) }), sink, in)
+			return got
+		}},
+	}
+
+	tests := []struct {
+		name   string
+		policy RecoverPolicy
+		want   []int
+		stop   bool
+	}{
+		{"skip", RecoverSkip, []int{0, 1, 3, 4}, false},
+		{"stop", RecoverStop, []int{0, 1}, true},
+	}
+
+	for _, st := range stages {
+		for _, tt := range tests {
+			t.Run(st.name+"/"+tt.name, func(t *testing.T) {
+				in, stopped := watch(FromSlice(0, []int{0, 1, 2, 3, 4}))
+
+				var errs []error
+				got := st.run(tt.policy, func(err error) { errs = append(errs, err) }, in)
+				if !slices.Equal(got, tt.want) {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+
+				var pe *PanicError
+				if len(errs) != 1 || !errors.As(errs[0], &pe) {
+					t.Fatalf("got %v, want a single *PanicError", errs)
+				}
+				if pe.Value != "boom" || pe.Policy != tt.policy || len(pe.Stack) == 0 {
+					t.Fatalf("got %+v, want the recovered panic under %v", pe, tt.policy)
+				}
+				if errors.Is(pe, ErrStop) != tt.stop {
+					t.Fatalf("errors.Is(err, ErrStop) = %v, want %v", !tt.stop, tt.stop)
+				}
+
+				// after a stop the rest of in is drained in the background
+				waitStopped(t, stopped)
+			})
+		}
+	}
+}
+
+func TestPanicErrorUnwrapsErrors(t *testing.T) {
+	mp := RecoverMapWithError(RecoverSkip, func(int) (int, error) { panic(io.EOF) })
+	if _, err := mp(1); !errors.Is(err, io.EOF) {
+		t.Fatalf("got %v, want it to wrap io.EOF", err)
+	}
+
+	mp = RecoverMapWithError(RecoverSkip, func(int) (int, error) { panic("boom") })
+	if _, err := mp(1); errors.Unwrap(err) != nil || errors.Is(err, ErrStop) {
+		t.Fatalf("got %v, want nothing wrapped", err)
+	}
+}
+
+func TestRecoverRouteFallsBack(t *testing.T) {
+	var errs []error
+	choose := RecoverRoute(func(i int) int { return panicAt2(i) % 2 }, 1, func(err error) { errs = append(errs, err) })
+
+	outs := Distribute(5, 2, choose, FromSlice(0, []int{0, 1, 2, 3, 4}))
+	if got := ToSlice(outs[0]); !slices.Equal(got, []int{0, 4}) {
+		t.Fatalf("out 0 got %v, want [0 4]", got)
+	}
+	if got := ToSlice(outs[1]); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("out 1 got %v, want [1 2 3]", got)
+	}
+
+	var pe *PanicError
+	if len(errs) != 1 || !errors.As(errs[0], &pe) || errors.Is(pe, ErrStop) {
+		t.Fatalf("got %v, want a single skipped *PanicError", errs)
+	}
+}
+
+func TestRecoverRouteWithRouter(t *testing.T) {
+	var errs []error
+	compare := RecoverRoute(func(i int) int { return panicAt2(i) }, -1, func(err error) { errs = append(errs, err) })
+
+	outs, rest := Router(5, []int{0, 1, 3, 4}, compare, FromSlice(0, []int{0, 1, 2, 3, 4}))
+	for i, out := range outs {
+		if got := ToSlice(out); len(got) != 1 {
+			t.Fatalf("out %d got %v, want a single item", i, got)
+		}
+	}
+	if got := ToSlice(rest); !slices.Equal(got, []int{2}) {
+		t.Fatalf("rest got %v, want [2]", got)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("got %v, want a single error", errs)
+	}
+}
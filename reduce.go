@@ -63,3 +63,79 @@ func windowWorker[T any, Acc any](window time.Duration, reduce func(T, Acc) Acc,
 		}
 	}
 }
+
+// ScanOptions tunes which intermediate accumulators Scan emits and how.
+type ScanOptions[Acc any] struct {
+	// Every emits only every Nth accumulator, along with the final accumulator when in is closed if
+	// it was not already emitted. 0 or 1 emits every accumulator.
+	Every int
+	// Changed, if set, emits an accumulator only when it returns true for the previously emitted and
+	// current accumulator. The first accumulator is always emitted.
+	Changed func(prev, next Acc) bool
+	// Clone, if set, is applied to each accumulator before it is emitted. Pointer, slice and map
+	// accumulators are updated in place by reduce so need cloning for each emitted value to remain a
+	// stable snapshot.
+	Clone func(Acc) Acc
+}
+
+// Scan applies reduce to each T read from in as Reduce does, emitting every intermediate
+// accumulator rather than only the last. Accumulators are emitted as is, see ScanWith to emit every
+// Nth, only on change, or to clone pointer accumulators.
+func Scan[T any, Acc any](size int, reduce func(T, Acc) Acc, acc Acc, in <-chan T) ChanPull[Acc] {
+	return ScanWith(size, reduce, acc, ScanOptions[Acc]{}, in)
+}
+
+// ScanWith behaves as Scan with the emitted accumulators tuned by opts.
+func ScanWith[T any, Acc any](size int, reduce func(T, Acc) Acc, acc Acc, opts ScanOptions[Acc], in <-chan T) ChanPull[Acc] {
+	out := make(chan Acc, size)
+
+	go scanWorker(reduce, acc, opts, in, out)
+
+	return out
+}
+
+func scanWorker[T any, Acc any](reduce func(T, Acc) Acc, acc Acc, opts ScanOptions[Acc], in <-chan T, out chan<- Acc) {
+	defer close(out)
+
+	var (
+		prev    Acc
+		emitted bool // whether any accumulator has been emitted, prev is only valid once true
+		skipped bool // whether the current accumulator was held back by Every
+	)
+
+	emit := func() {
+		skipped = false
+		if opts.Changed != nil && emitted && !opts.Changed(prev, acc) {
+			return
+		}
+
+		next := acc
+		if opts.Clone != nil {
+			next = opts.Clone(acc)
+		}
+
+		// keep our own copy to compare against as the emitted value may be mutated downstream
+		prev, emitted = next, true
+		if opts.Clone != nil && opts.Changed != nil {
+			prev = opts.Clone(acc)
+		}
+
+		out <- next
+	}
+
+	n := 0
+	for t := range in {
+		acc = reduce(t, acc)
+
+		if n++; opts.Every > 1 && n%opts.Every != 0 {
+			skipped = true
+			continue
+		}
+
+		emit()
+	}
+
+	if skipped {
+		emit()
+	}
+}
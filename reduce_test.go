@@ -0,0 +1,106 @@
+package pipes
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func sum(t, acc int) int { return acc + t }
+
+func TestScanEvery(t *testing.T) {
+	tests := []struct {
+		name  string
+		every int
+		in    []int
+		want  []int
+	}{
+		{"zero emits all", 0, []int{1, 2, 3, 4}, []int{1, 3, 6, 10}},
+		{"one emits all", 1, []int{1, 2, 3, 4}, []int{1, 3, 6, 10}},
+		{"every other", 2, []int{1, 2, 3, 4}, []int{3, 10}},
+		{"final held back", 3, []int{1, 2, 3, 4}, []int{6, 10}},
+		{"only final", 10, []int{1, 2, 3, 4}, []int{10}},
+		{"empty", 2, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ToSlice(ScanWith(0, sum, 0, ScanOptions[int]{Every: tt.every}, FromSlice(0, tt.in)))
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanChanged(t *testing.T) {
+	maximum := func(t, acc int) int { return max(t, acc) }
+	differs := func(prev, next int) bool { return prev != next }
+
+	tests := []struct {
+		name string
+		opts ScanOptions[int]
+		want []int
+	}{
+		{"unset emits all", ScanOptions[int]{}, []int{1, 3, 3, 3, 5, 5}},
+		{"only changes", ScanOptions[int]{Changed: differs}, []int{1, 3, 5}},
+		{"first always emitted", ScanOptions[int]{Changed: func(int, int) bool { return false }}, []int{1}},
+		{"with every", ScanOptions[int]{Every: 2, Changed: differs}, []int{3, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ToSlice(ScanWith(0, maximum, 0, tt.opts, FromSlice(0, []int{1, 3, 2, 3, 5, 4})))
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanClone(t *testing.T) {
+	set := func(t int, acc map[int]bool) map[int]bool { acc[t] = true; return acc }
+	clone := func(acc map[int]bool) map[int]bool { return maps.Clone(acc) }
+
+	tests := []struct {
+		name string
+		opts ScanOptions[map[int]bool]
+		want []int // the number of items in each emitted accumulator
+	}{
+		// every emitted value is the one map updated in place
+		{"unset shares", ScanOptions[map[int]bool]{}, []int{3, 3, 3}},
+		{"snapshots", ScanOptions[map[int]bool]{Clone: clone}, []int{1, 2, 3}},
+		// mutating an emitted snapshot downstream must not hide a change from Changed
+		{"snapshots compared", ScanOptions[map[int]bool]{
+			Clone:   clone,
+			Changed: func(prev, next map[int]bool) bool { return len(prev) != len(next) },
+		}, []int{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := ScanWith(0, set, map[int]bool{}, tt.opts, FromSlice(0, []int{1, 2, 3}))
+
+			var got []map[int]bool
+			for m := range out {
+				got = append(got, m)
+				if tt.opts.Clone != nil {
+					// a snapshot belongs to the receiver, which is free to modify it
+					m[-len(got)] = true
+				}
+			}
+
+			lens := make([]int, len(got))
+			for i, m := range got {
+				for k := range m {
+					if k > 0 {
+						lens[i]++
+					}
+				}
+			}
+			if !slices.Equal(lens, tt.want) {
+				t.Fatalf("got lengths %v, want %v", lens, tt.want)
+			}
+		})
+	}
+}
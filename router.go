@@ -1,6 +1,12 @@
 package pipes
 
 func Router[T any, N comparable](size int, matches []N, compare func(T) N, in <-chan T) ([]ChanPull[T], ChanPull[T]) {
+	return RouterWithMetrics(nil, "", size, matches, compare, in)
+}
+
+// RouterWithMetrics behaves as Router reporting the activity of its worker to m under the given
+// stage name. Items pushed to orElse are counted as out.
+func RouterWithMetrics[T any, N comparable](m Metrics, stage string, size int, matches []N, compare func(T) N, in <-chan T) ([]ChanPull[T], ChanPull[T]) {
 	orElse := make(chan T, size)
 	outs := make([]ChanPull[T], len(matches))
 	routes := make(map[N]chan<- T, len(matches))
@@ -10,12 +16,12 @@ func Router[T any, N comparable](size int, matches []N, compare func(T) N, in <-
 		routes[match] = out
 	}
 
-	go routerWorker(compare, in, routes, orElse)
+	go routerWorker(NewMeter(m, stage), compare, in, routes, orElse)
 
 	return outs, orElse
 }
 
-func routerWorker[T any, N comparable](compare func(T) N, in <-chan T, routes map[N]chan<- T, orElse chan<- T) {
+func routerWorker[T any, N comparable](mt *Meter, compare func(T) N, in <-chan T, routes map[N]chan<- T, orElse chan<- T) {
 	defer func() {
 		for _, out := range routes {
 			close(out)
@@ -25,16 +31,25 @@ func routerWorker[T any, N comparable](compare func(T) N, in <-chan T, routes ma
 	}()
 
 	for t := range in {
-		if route, exists := routes[compare(t)]; exists {
-			route <- t
+		start := mt.Received()
+		route, exists := routes[compare(t)]
+		mt.Processed(start, nil)
+		if exists {
+			MeterSend(mt, route, t)
 			continue
 		}
 
-		orElse <- t
+		MeterSend(mt, orElse, t)
 	}
 }
 
 func RouterWithSink[T any, N comparable](size int, matches []N, compare func(T) N, sink func(T), in <-chan T) []ChanPull[T] {
+	return RouterWithSinkMetrics(nil, "", size, matches, compare, sink, in)
+}
+
+// RouterWithSinkMetrics behaves as RouterWithSink reporting the activity of its worker to m under
+// the given stage name. Items passed to sink are counted as out.
+func RouterWithSinkMetrics[T any, N comparable](m Metrics, stage string, size int, matches []N, compare func(T) N, sink func(T), in <-chan T) []ChanPull[T] {
 	outs := make([]ChanPull[T], len(matches))
 	routes := make(map[N]chan<- T, len(matches))
 	for i, match := range matches {
@@ -43,12 +58,12 @@ func RouterWithSink[T any, N comparable](size int, matches []N, compare func(T)
 		routes[match] = out
 	}
 
-	go routerWithSinkWorker(compare, in, routes, sink)
+	go routerWithSinkWorker(NewMeter(m, stage), compare, in, routes, sink)
 
 	return outs
 }
 
-func routerWithSinkWorker[T any, N comparable](compare func(T) N, in <-chan T, routes map[N]chan<- T, sink func(T)) {
+func routerWithSinkWorker[T any, N comparable](mt *Meter, compare func(T) N, in <-chan T, routes map[N]chan<- T, sink func(T)) {
 	defer func() {
 		for _, out := range routes {
 			close(out)
@@ -56,12 +71,16 @@ func routerWithSinkWorker[T any, N comparable](compare func(T) N, in <-chan T, r
 	}()
 
 	for t := range in {
-		if route, exists := routes[compare(t)]; exists {
-			route <- t
+		start := mt.Received()
+		route, exists := routes[compare(t)]
+		mt.Processed(start, nil)
+		if exists {
+			MeterSend(mt, route, t)
 			continue
 		}
 
 		sink(t)
+		mt.Consumed()
 	}
 }
 
@@ -88,6 +107,12 @@ func roundRobinChooser[T any](count int) func(T) int {
 }
 
 func Distribute[T any](size, count int, choose func(T) int, in <-chan T) []ChanPull[T] {
+	return DistributeWithMetrics(nil, "", size, count, choose, in)
+}
+
+// DistributeWithMetrics behaves as Distribute reporting the activity of its worker to m under the
+// given stage name.
+func DistributeWithMetrics[T any](m Metrics, stage string, size, count int, choose func(T) int, in <-chan T) []ChanPull[T] {
 	if count < 1 {
 		return nil
 	}
@@ -100,12 +125,12 @@ func Distribute[T any](size, count int, choose func(T) int, in <-chan T) []ChanP
 		pushes[i] = ch
 	}
 
-	go distrbuteWorker(choose, in, pushes)
+	go distrbuteWorker(NewMeter(m, stage), choose, in, pushes)
 
 	return outs
 }
 
-func distrbuteWorker[T any](choose func(T) int, in <-chan T, outs []chan<- T) {
+func distrbuteWorker[T any](mt *Meter, choose func(T) int, in <-chan T, outs []chan<- T) {
 	defer func() {
 		for _, out := range outs {
 			close(out)
@@ -113,6 +138,9 @@ func distrbuteWorker[T any](choose func(T) int, in <-chan T, outs []chan<- T) {
 	}()
 
 	for t := range in {
-		outs[choose(t)] <- t
+		start := mt.Received()
+		i := choose(t)
+		mt.Processed(start, nil)
+		MeterSend(mt, outs[i], t)
 	}
 }
@@ -0,0 +1,244 @@
+package pipes
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Shutdown coordinates the graceful shutdown of every stage created with it. Calling Shutdown.Shutdown
+// first asks sources to stop producing while all other stages finish their in-flight items,
+// flushing anything partially accumulated as their inputs close. Stages still blocked once the
+// deadline passes are abandoned and report how many items they dropped.
+//
+// Stages participate by calling Add before starting, Done once exited, and selecting on Stopping
+// and Abandoned wherever they may block.
+type Shutdown struct {
+	stopping    chan struct{}
+	abandoned   chan struct{}
+	stopOnce    sync.Once
+	abandonOnce sync.Once
+	wg          sync.WaitGroup
+
+	mu      sync.Mutex
+	dropped map[string]int
+}
+
+// NewShutdown returns a new Shutdown ready to be passed to WithShutdown stages.
+func NewShutdown() *Shutdown {
+	return &Shutdown{
+		stopping:  make(chan struct{}),
+		abandoned: make(chan struct{}),
+		dropped:   make(map[string]int),
+	}
+}
+
+// Add registers delta stages as running. Stages must register when they are created, before
+// starting any goroutine or blocking, and all stages must be created before Shutdown is called.
+func (s *Shutdown) Add(delta int) {
+	s.wg.Add(delta)
+}
+
+// Done registers a running stage as exited.
+func (s *Shutdown) Done() {
+	s.wg.Done()
+}
+
+// Stopping returns a channel that is closed once shutdown has started. Sources should stop
+// producing once this is closed.
+func (s *Shutdown) Stopping() <-chan struct{} {
+	return s.stopping
+}
+
+// Abandoned returns a channel that is closed once the shutdown deadline has passed. Stages should
+// stop blocking, report any items they drop and exit once this is closed.
+func (s *Shutdown) Abandoned() <-chan struct{} {
+	return s.abandoned
+}
+
+// Drop records n items dropped by the given stage.
+func (s *Shutdown) Drop(stage string, n int) {
+	if n < 1 {
+		return
+	}
+
+	s.mu.Lock()
+	s.dropped[stage] += n
+	s.mu.Unlock()
+}
+
+// Shutdown stops all sources and waits for every stage to finish until ctx is done. If ctx is done
+// first the remaining stages are abandoned and ctx.Err() is returned. In both cases the number of
+// items dropped at each stage is returned once all stages have exited. Stages blocked within a user
+// supplied function can only exit once that function returns.
+func (s *Shutdown) Shutdown(ctx context.Context) (map[string]int, error) {
+	s.stopOnce.Do(func() { close(s.stopping) })
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		s.abandonOnce.Do(func() { close(s.abandoned) })
+		<-done
+	}
+
+	return s.Dropped(), err
+}
+
+// Dropped returns the number of items dropped so far keyed by stage name.
+func (s *Shutdown) Dropped() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dropped := make(map[string]int, len(s.dropped))
+	for stage, n := range s.dropped {
+		dropped[stage] = n
+	}
+
+	return dropped
+}
+
+// Send pushes t onto out returning true, unless s is abandoned first in which case t is recorded as
+// dropped by stage and false is returned.
+func Send[T any](s *Shutdown, stage string, out chan<- T, t T) bool {
+	select {
+	case out <- t:
+		return true
+	case <-s.abandoned:
+		s.Drop(stage, 1)
+		return false
+	}
+}
+
+// Receive pulls the next T from in returning false if in is closed, or if s is abandoned first in
+// which case any items left buffered in in are recorded as dropped by stage.
+func Receive[T any](s *Shutdown, stage string, in <-chan T) (T, bool) {
+	var zero T
+
+	// once abandoned stop even while items remain buffered, select would otherwise pick at random
+	select {
+	case <-s.abandoned:
+		s.Drop(stage, len(in))
+		return zero, false
+	default:
+	}
+
+	select {
+	case t, ok := <-in:
+		return t, ok
+	case <-s.abandoned:
+		s.Drop(stage, len(in))
+		return zero, false
+	}
+}
+
+func SourceWithShutdown[T any](s *Shutdown, stage string, repeat, size int, source func() T) ChanPull[T] {
+	out := make(chan T, size)
+
+	s.Add(1)
+	go sourceWithShutdownWorker(s, stage, repeat, source, out)
+
+	return out
+}
+
+func sourceWithShutdownWorker[T any](s *Shutdown, stage string, repeat int, source func() T, out chan<- T) {
+	defer s.Done()
+	defer close(out)
+
+	for i := 0; repeat == RepeatForever || i < repeat; i++ {
+		select {
+		case <-s.stopping:
+			return
+		default:
+		}
+
+		if !Send(s, stage, out, source()) {
+			return
+		}
+	}
+}
+
+func WindowWithShutdown[T any, Acc any](s *Shutdown, stage string, size int, window time.Duration, reduce func(T, Acc) Acc, acc func() Acc, in <-chan T) ChanPull[Acc] {
+	out := make(chan Acc, size)
+
+	s.Add(1)
+	go windowWithShutdownWorker(s, stage, window, reduce, acc, in, out)
+
+	return out
+}
+
+func windowWithShutdownWorker[T any, Acc any](s *Shutdown, stage string, window time.Duration, reduce func(T, Acc) Acc, acc func() Acc, in <-chan T, out chan<- Acc) {
+	defer s.Done()
+	defer close(out)
+
+	ticker := time.NewTicker(window)
+	defer ticker.Stop()
+
+	// pending tracks the items reduced into ac so they can be reported if ac is dropped
+	ac, pending := acc(), 0
+	emit := func() bool {
+		select {
+		case out <- ac:
+			return true
+		case <-s.abandoned:
+			s.Drop(stage, pending+len(in))
+			return false
+		}
+	}
+
+	for {
+		select {
+		case t, ok := <-in:
+			if !ok {
+				emit()
+				return
+			}
+			ac = reduce(t, ac)
+			pending++
+
+		case <-ticker.C:
+			if !emit() {
+				return
+			}
+			ac, pending = acc(), 0
+
+		case <-s.abandoned:
+			s.Drop(stage, pending+len(in))
+			return
+		}
+	}
+}
+
+// SinkWithShutdown passes each T read from in to sink until in is closed, or s is abandoned in
+// which case any items left buffered in in are recorded as dropped. The stage is registered with s
+// before returning, so a Shutdown started at any point afterwards waits for it, and runs in the
+// background closing the returned channel once it has exited.
+func SinkWithShutdown[T any](s *Shutdown, stage string, sink func(T), in <-chan T) <-chan struct{} {
+	done := make(chan struct{})
+
+	s.Add(1)
+	go sinkWithShutdownWorker(s, stage, sink, in, done)
+
+	return done
+}
+
+func sinkWithShutdownWorker[T any](s *Shutdown, stage string, sink func(T), in <-chan T, done chan<- struct{}) {
+	defer close(done)
+	defer s.Done()
+
+	for {
+		t, ok := Receive(s, stage, in)
+		if !ok {
+			return
+		}
+
+		sink(t)
+	}
+}
@@ -0,0 +1,73 @@
+package pipes
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestShutdownWaitsForSinkStartedBeforeIt(t *testing.T) {
+	s := NewShutdown()
+	in := make(chan int)
+
+	release := make(chan struct{})
+	var sunk []int
+	done := SinkWithShutdown(s, "sink", func(i int) {
+		<-release
+		sunk = append(sunk, i)
+	}, in)
+
+	in <- 1
+	close(in)
+
+	shut := make(chan struct{})
+	go func() {
+		defer close(shut)
+		if _, err := s.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+
+	select {
+	case <-shut:
+		t.Fatal("shutdown returned while the sink was still processing")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	<-shut
+	<-done
+
+	if len(sunk) != 1 || sunk[0] != 1 {
+		t.Fatalf("got %v, want [1]", sunk)
+	}
+}
+
+func TestShutdownAbandonsBlockedSink(t *testing.T) {
+	s := NewShutdown()
+	in := make(chan int, 3)
+	in <- 1
+	in <- 2
+	in <- 3
+
+	block := make(chan struct{})
+	done := SinkWithShutdown(s, "sink", func(int) { <-block }, in)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	go func() {
+		<-s.Abandoned()
+		close(block)
+	}()
+
+	dropped, err := s.Shutdown(ctx)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("got %v, want deadline exceeded", err)
+	}
+	<-done
+
+	if dropped["sink"] != 2 {
+		t.Fatalf("got %v dropped, want 2 items dropped by sink", dropped)
+	}
+}
@@ -1,33 +1,73 @@
 package pipes
 
 func Sink[T any](sink func(T), in <-chan T) {
+	SinkWithMetrics(nil, "", sink, in)
+}
+
+// SinkWithMetrics behaves as Sink reporting each item consumed to m under the given stage name.
+func SinkWithMetrics[T any](m Metrics, stage string, sink func(T), in <-chan T) {
+	mt := NewMeter(m, stage)
+
 	for t := range in {
+		start := mt.Received()
 		sink(t)
+		mt.Processed(start, nil)
+		mt.Consumed()
 	}
 }
 
 func SinkWithError[T any](size int, sink func(T) error, in <-chan T) ChanPull[error] {
+	return SinkWithErrorMetrics(nil, "", size, sink, in)
+}
+
+// SinkWithErrorMetrics behaves as SinkWithError reporting the activity of its worker to m under the
+// given stage name.
+func SinkWithErrorMetrics[T any](m Metrics, stage string, size int, sink func(T) error, in <-chan T) ChanPull[error] {
 	err := make(chan error, size)
 
-	go sinkWithErrorWorker(sink, in, err)
+	go sinkWithErrorWorker(NewMeter(m, stage), sink, in, err)
 
 	return err
 }
 
-func sinkWithErrorWorker[T any](sink func(T) error, in <-chan T, err chan<- error) {
+func sinkWithErrorWorker[T any](mt *Meter, sink func(T) error, in <-chan T, err chan<- error) {
 	defer close(err)
 
 	for t := range in {
-		if er := sink(t); er != nil {
+		start := mt.Received()
+		er := sink(t)
+		mt.Processed(start, er)
+		if er != nil {
 			err <- er
+			if stopping(er, in) {
+				return
+			}
+			continue
 		}
+		mt.Consumed()
 	}
 }
 
 func SinkWithErrorSink[T any](sink func(T) error, errSink func(error), in <-chan T) {
+	SinkWithErrorSinkMetrics(nil, "", sink, errSink, in)
+}
+
+// SinkWithErrorSinkMetrics behaves as SinkWithErrorSink reporting each item consumed or failed to m
+// under the given stage name.
+func SinkWithErrorSinkMetrics[T any](m Metrics, stage string, sink func(T) error, errSink func(error), in <-chan T) {
+	mt := NewMeter(m, stage)
+
 	for t := range in {
-		if err := sink(t); err != nil {
+		start := mt.Received()
+		err := sink(t)
+		mt.Processed(start, err)
+		if err != nil {
 			errSink(err)
+			if stopping(err, in) {
+				return
+			}
+			continue
 		}
+		mt.Consumed()
 	}
 }
@@ -0,0 +1,108 @@
+package sketch
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/curlymon/pipes"
+)
+
+// Bloom is a bounded memory set membership filter. Contains never reports a false negative but
+// reports false positives at a rate growing with the number of items added.
+type Bloom[T any] struct {
+	size   uint64 // in bits
+	hashes uint64
+	hash   Hasher[T]
+	bits   []uint64
+}
+
+// NewBloom returns an empty Bloom of size bits probed by hashes derived hashes, each at least one.
+func NewBloom[T any](size uint64, hashes int, hash Hasher[T]) *Bloom[T] {
+	size = max(size, 1)
+
+	return &Bloom[T]{size: size, hashes: uint64(max(hashes, 1)), hash: hash, bits: make([]uint64, (size+63)/64)}
+}
+
+// NewBloomWithRate returns an empty Bloom sized to hold n items with a false positive rate of fp. It
+// panics unless n is positive and fp is within (0, 1).
+func NewBloomWithRate[T any](n int, fp float64, hash Hasher[T]) *Bloom[T] {
+	if n <= 0 {
+		panic(fmt.Sprintf("sketch: NewBloomWithRate n must be positive, got %d", n))
+	}
+	if !(fp > 0 && fp < 1) {
+		panic(fmt.Sprintf("sketch: NewBloomWithRate fp must be within (0, 1), got %v", fp))
+	}
+
+	size := math.Ceil(-float64(n) * math.Log(fp) / (math.Ln2 * math.Ln2))
+	hashes := math.Round(size / float64(n) * math.Ln2)
+
+	return NewBloom(uint64(size), int(hashes), hash)
+}
+
+func (b *Bloom[T]) Add(t T) {
+	b.AddIfAbsent(t)
+}
+
+// AddIfAbsent adds t returning true if t was not already contained.
+func (b *Bloom[T]) AddIfAbsent(t T) bool {
+	h := b.hash(t)
+	added := false
+	for i := uint64(0); i < b.hashes; i++ {
+		bit := probe(h, i, b.size)
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if b.bits[word]&mask == 0 {
+			b.bits[word] |= mask
+			added = true
+		}
+	}
+
+	return added
+}
+
+// Contains reports whether t may have been added, false means it certainly has not.
+func (b *Bloom[T]) Contains(t T) bool {
+	h := b.hash(t)
+	for i := uint64(0); i < b.hashes; i++ {
+		bit := probe(h, i, b.size)
+		if b.bits[bit/64]&(uint64(1)<<(bit%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Merge folds o into b leaving b containing the union of both. Both must share size, hashes and
+// Hasher.
+func (b *Bloom[T]) Merge(o *Bloom[T]) error {
+	if b.size != o.size || b.hashes != o.hashes {
+		return ErrIncompatible
+	}
+
+	for i, w := range o.bits {
+		b.bits[i] |= w
+	}
+
+	return nil
+}
+
+// Distinct pushes each T read from in that b does not already contain, adding it to b. Memory is
+// bounded by b at the cost of occasionally dropping an unseen T that is a false positive. Sharing b
+// between stages is not safe.
+func Distinct[T any](size int, b *Bloom[T], in <-chan T) pipes.ChanPull[T] {
+	out := make(chan T, size)
+
+	go distinctWorker(b, in, out)
+
+	return out
+}
+
+func distinctWorker[T any](b *Bloom[T], in <-chan T, out chan<- T) {
+	defer close(out)
+
+	for t := range in {
+		if b.AddIfAbsent(t) {
+			out <- t
+		}
+	}
+}
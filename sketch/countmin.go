@@ -0,0 +1,87 @@
+package sketch
+
+import (
+	"fmt"
+	"math"
+)
+
+// CountMin estimates how often each item has been seen in depth rows of width counters. Estimates
+// never undercount and overcount by at most e/width of the total with probability 1-e^-depth.
+type CountMin[T any] struct {
+	width, depth uint64
+	hash         Hasher[T]
+	counts       []uint64 // depth rows of width counters
+	total        uint64
+}
+
+// NewCountMin returns an empty CountMin of depth rows of width counters, each at least one.
+func NewCountMin[T any](width, depth int, hash Hasher[T]) *CountMin[T] {
+	w, d := uint64(max(width, 1)), uint64(max(depth, 1))
+
+	return &CountMin[T]{width: w, depth: d, hash: hash, counts: make([]uint64, w*d)}
+}
+
+// NewCountMinWithError returns an empty CountMin sized so estimates overcount by at most epsilon of
+// the total with probability 1-delta. It panics unless epsilon and delta are within (0, 1).
+func NewCountMinWithError[T any](epsilon, delta float64, hash Hasher[T]) *CountMin[T] {
+	if !(epsilon > 0 && epsilon < 1) {
+		panic(fmt.Sprintf("sketch: NewCountMinWithError epsilon must be within (0, 1), got %v", epsilon))
+	}
+	if !(delta > 0 && delta < 1) {
+		panic(fmt.Sprintf("sketch: NewCountMinWithError delta must be within (0, 1), got %v", delta))
+	}
+
+	width := int(math.Ceil(math.E / epsilon))
+	depth := int(math.Ceil(math.Log(1 / delta)))
+
+	return NewCountMin(width, depth, hash)
+}
+
+// CountMins returns a function creating a new CountMin, for use as the acc function of
+// pipes.Window.
+func CountMins[T any](width, depth int, hash Hasher[T]) func() *CountMin[T] {
+	return func() *CountMin[T] { return NewCountMin(width, depth, hash) }
+}
+
+func (c *CountMin[T]) Add(t T) {
+	c.AddN(t, 1)
+}
+
+// AddN records t as seen n times.
+func (c *CountMin[T]) AddN(t T, n uint64) {
+	h := c.hash(t)
+	for i := uint64(0); i < c.depth; i++ {
+		c.counts[i*c.width+probe(h, i, c.width)] += n
+	}
+	c.total += n
+}
+
+// Estimate returns the estimated number of times t has been seen.
+func (c *CountMin[T]) Estimate(t T) uint64 {
+	h := c.hash(t)
+	estimate := uint64(math.MaxUint64)
+	for i := uint64(0); i < c.depth; i++ {
+		estimate = min(estimate, c.counts[i*c.width+probe(h, i, c.width)])
+	}
+
+	return estimate
+}
+
+// Total returns the number of items seen.
+func (c *CountMin[T]) Total() uint64 {
+	return c.total
+}
+
+// Merge folds o into c as if c had seen every item o has. Both must share dimensions and Hasher.
+func (c *CountMin[T]) Merge(o *CountMin[T]) error {
+	if c.width != o.width || c.depth != o.depth {
+		return ErrIncompatible
+	}
+
+	for i, n := range o.counts {
+		c.counts[i] += n
+	}
+	c.total += o.total
+
+	return nil
+}
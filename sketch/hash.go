@@ -0,0 +1,76 @@
+// Package sketch provides approximate, bounded memory aggregations for high cardinality streams.
+//
+// Every sketch implements agg.Aggregator so it plugs into pipes.Reduce and pipes.Window through
+// agg.Add, and every sketch can be merged with another built with the same parameters so that
+// per-worker results from async pipelines can be combined.
+package sketch
+
+import (
+	"errors"
+	"hash/maphash"
+
+	"github.com/curlymon/pipes"
+)
+
+// ErrIncompatible is returned when merging sketches built with differing parameters.
+var ErrIncompatible = errors.New("sketch: incompatible sketch parameters")
+
+// Hasher returns a well mixed 64 bit hash of a T. Sketches that are to be merged must share the
+// same Hasher.
+type Hasher[T any] func(T) uint64
+
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// HashString hashes s with FNV-1a finished with a 64 bit mixer. The hash is stable across
+// processes, so sketches built with it can be merged across machines.
+func HashString(s string) uint64 {
+	h := uint64(fnvOffset)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= fnvPrime
+	}
+
+	return mix(h)
+}
+
+// HashBytes hashes b as HashString.
+func HashBytes(b []byte) uint64 {
+	h := uint64(fnvOffset)
+	for _, c := range b {
+		h ^= uint64(c)
+		h *= fnvPrime
+	}
+
+	return mix(h)
+}
+
+// HashInteger hashes t with a 64 bit mixer. The hash is stable across processes.
+func HashInteger[T pipes.Integer](t T) uint64 {
+	return mix(uint64(t))
+}
+
+// HashComparable returns a Hasher for any comparable T using hash/maphash. The hash is only stable
+// for a given seed within a single process, so sketches built with it can only be merged within
+// that process.
+func HashComparable[T comparable](seed maphash.Seed) Hasher[T] {
+	return func(t T) uint64 { return maphash.Comparable(seed, t) }
+}
+
+// mix is the splitmix64 finalizer, spreading every input bit across the whole hash.
+func mix(h uint64) uint64 {
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
+
+// probe derives the i-th of a family of indexes into n slots from a single hash by double hashing.
+func probe(h uint64, i, n uint64) uint64 {
+	lo, hi := h&0xffffffff, h>>32|1
+	return (lo + i*hi) % n
+}
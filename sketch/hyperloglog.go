@@ -0,0 +1,97 @@
+package sketch
+
+import (
+	"math"
+	"math/bits"
+)
+
+const (
+	MinPrecision = 4
+	MaxPrecision = 18
+)
+
+// HyperLogLog estimates the number of distinct items seen using 2^precision single byte registers,
+// with a standard error of about 1.04/sqrt(2^precision), roughly 0.8% at the default precision of
+// 14 using 16KiB.
+type HyperLogLog[T any] struct {
+	precision uint8
+	hash      Hasher[T]
+	registers []uint8
+}
+
+// NewHyperLogLog returns an empty HyperLogLog. Precision is clamped to within MinPrecision and
+// MaxPrecision, zero uses 14.
+func NewHyperLogLog[T any](precision uint8, hash Hasher[T]) *HyperLogLog[T] {
+	if precision == 0 {
+		precision = 14
+	}
+	precision = min(max(precision, MinPrecision), MaxPrecision)
+
+	return &HyperLogLog[T]{precision: precision, hash: hash, registers: make([]uint8, 1<<precision)}
+}
+
+// HyperLogLogs returns a function creating a new HyperLogLog, for use as the acc function of
+// pipes.Window.
+func HyperLogLogs[T any](precision uint8, hash Hasher[T]) func() *HyperLogLog[T] {
+	return func() *HyperLogLog[T] { return NewHyperLogLog(precision, hash) }
+}
+
+func (h *HyperLogLog[T]) Add(t T) {
+	x := h.hash(t)
+	i := x >> (64 - h.precision)
+	// rank of the first set bit in the remaining bits, capped for an all zero remainder
+	rank := uint8(bits.LeadingZeros64(x<<h.precision|1<<(h.precision-1))) + 1
+	if rank > h.registers[i] {
+		h.registers[i] = rank
+	}
+}
+
+// Merge folds o into h as if h had seen every item o has. Both must share precision and Hasher.
+func (h *HyperLogLog[T]) Merge(o *HyperLogLog[T]) error {
+	if h.precision != o.precision {
+		return ErrIncompatible
+	}
+
+	for i, r := range o.registers {
+		if r > h.registers[i] {
+			h.registers[i] = r
+		}
+	}
+
+	return nil
+}
+
+// Count returns the estimated number of distinct items seen.
+func (h *HyperLogLog[T]) Count() uint64 {
+	m := float64(len(h.registers))
+
+	sum, zeros := 0.0, 0
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	estimate := h.alpha() * m * m / sum
+
+	// small cardinalities are better served by linear counting of the empty registers
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+
+	return uint64(estimate + 0.5)
+}
+
+func (h *HyperLogLog[T]) alpha() float64 {
+	switch m := len(h.registers); m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	default:
+		return 0.7213 / (1 + 1.079/float64(m))
+	}
+}
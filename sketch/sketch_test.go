@@ -0,0 +1,206 @@
+package sketch
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestBloomWithRate(t *testing.T) {
+	b := NewBloomWithRate(1000, 0.01, HashInteger[int])
+	for i := range 1000 {
+		b.Add(i)
+	}
+
+	for i := range 1000 {
+		if !b.Contains(i) {
+			t.Fatalf("false negative for %d", i)
+		}
+	}
+
+	fp := 0
+	for i := 1000; i < 11000; i++ {
+		if b.Contains(i) {
+			fp++
+		}
+	}
+	if rate := float64(fp) / 10000; rate > 0.02 {
+		t.Fatalf("got false positive rate %v, want about 0.01", rate)
+	}
+}
+
+func TestConstructorsRejectInvalidRates(t *testing.T) {
+	for name, fn := range map[string]func(){
+		"bloom n 0":        func() { NewBloomWithRate(0, 0.01, HashInteger[int]) },
+		"bloom fp 0":       func() { NewBloomWithRate(10, 0, HashInteger[int]) },
+		"bloom fp 1":       func() { NewBloomWithRate(10, 1, HashInteger[int]) },
+		"bloom fp NaN":     func() { NewBloomWithRate(10, math.NaN(), HashInteger[int]) },
+		"countmin eps 0":   func() { NewCountMinWithError(0, 0.01, HashInteger[int]) },
+		"countmin delta 1": func() { NewCountMinWithError(0.01, 1, HashInteger[int]) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("want a panic")
+				}
+			}()
+			fn()
+		})
+	}
+}
+
+func TestHyperLogLogCount(t *testing.T) {
+	for _, n := range []int{100, 10000, 200000} {
+		h := NewHyperLogLog(14, HashInteger[int])
+		for i := range n {
+			// duplicates do not count
+			h.Add(i)
+			h.Add(i)
+		}
+
+		// three standard errors of 1.04/sqrt(2^14)
+		if got := float64(h.Count()); math.Abs(got-float64(n))/float64(n) > 3*1.04/128 {
+			t.Fatalf("got %v distinct, want %d within 2.4%%", got, n)
+		}
+	}
+}
+
+func TestHyperLogLogMerge(t *testing.T) {
+	a, b, all := NewHyperLogLog(12, HashInteger[int]), NewHyperLogLog(12, HashInteger[int]), NewHyperLogLog(12, HashInteger[int])
+	for i := range 30000 {
+		a.Add(i)
+		all.Add(i)
+	}
+	for i := 20000; i < 50000; i++ {
+		b.Add(i)
+		all.Add(i)
+	}
+
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if a.Count() != all.Count() {
+		t.Fatalf("got %d merged, want %d as if seen by one", a.Count(), all.Count())
+	}
+
+	if err := a.Merge(NewHyperLogLog(10, HashInteger[int])); err != ErrIncompatible {
+		t.Fatalf("got %v merging differing precision, want %v", err, ErrIncompatible)
+	}
+}
+
+// zipf returns a skewed stream of n items along with the true count of each.
+func zipf(n int, seed uint64) ([]int, map[int]uint64) {
+	r := rand.New(rand.NewPCG(seed, 0))
+	z := rand.NewZipf(r, 1.2, 1, 10000)
+
+	items, counts := make([]int, n), map[int]uint64{}
+	for i := range items {
+		items[i] = int(z.Uint64())
+		counts[items[i]]++
+	}
+
+	return items, counts
+}
+
+func TestCountMinEstimate(t *testing.T) {
+	const eps = 0.001
+
+	a, b := NewCountMinWithError(eps, 0.01, HashInteger[int]), NewCountMinWithError(eps, 0.01, HashInteger[int])
+	itemsA, counts := zipf(50000, 1)
+	itemsB, countsB := zipf(50000, 2)
+	for _, i := range itemsA {
+		a.Add(i)
+	}
+	for _, i := range itemsB {
+		b.Add(i)
+	}
+
+	check := func(c *CountMin[int], counts map[int]uint64) {
+		t.Helper()
+
+		bound := uint64(math.Ceil(eps * float64(c.Total())))
+		for item, want := range counts {
+			got := c.Estimate(item)
+			if got < want {
+				t.Fatalf("item %d got %d, want at least the true count %d", item, got, want)
+			}
+			if got-want > bound {
+				t.Fatalf("item %d got %d, want within %d of %d", item, got, bound, want)
+			}
+		}
+	}
+	check(a, counts)
+
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	for item, n := range countsB {
+		counts[item] += n
+	}
+	if a.Total() != 100000 {
+		t.Fatalf("got total %d, want 100000", a.Total())
+	}
+	check(a, counts)
+
+	if err := a.Merge(NewCountMin(10, 1, HashInteger[int])); err != ErrIncompatible {
+		t.Fatalf("got %v merging differing dimensions, want %v", err, ErrIncompatible)
+	}
+}
+
+func checkSpaceSaving(t *testing.T, s *SpaceSaving[int], k int, total uint64, counts map[int]uint64) {
+	t.Helper()
+
+	top := s.Top(0)
+	if len(top) != k {
+		t.Fatalf("got %d tracked, want %d", len(top), k)
+	}
+
+	for i, c := range top {
+		if i > 0 && c.Count > top[i-1].Count {
+			t.Fatalf("Top not by descending count at %d: %v", i, top)
+		}
+
+		want := counts[c.Item]
+		if c.Count < want || c.Count-c.Err > want {
+			t.Fatalf("item %d got count %d err %d, want the true count %d within", c.Item, c.Count, c.Err, want)
+		}
+	}
+
+	// every item seen more than total/k times is tracked
+	for item, n := range counts {
+		if _, tracked := s.index[item]; n > total/uint64(k) && !tracked {
+			t.Fatalf("heavy item %d seen %d times not tracked", item, n)
+		}
+	}
+}
+
+func TestSpaceSaving(t *testing.T) {
+	const k = 50
+
+	a, b := NewSpaceSaving[int](k), NewSpaceSaving[int](k)
+	itemsA, counts := zipf(50000, 3)
+	itemsB, countsB := zipf(50000, 4)
+	for _, i := range itemsA {
+		a.Add(i)
+	}
+	for _, i := range itemsB {
+		b.Add(i)
+	}
+	checkSpaceSaving(t, a, k, 50000, counts)
+
+	if top := a.Top(3); len(top) != 3 || top[0].Item != 0 {
+		t.Fatalf("got top %v, want the most frequent item 0 first", top)
+	}
+
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	for item, n := range countsB {
+		counts[item] += n
+	}
+	checkSpaceSaving(t, a, k, 100000, counts)
+
+	if err := a.Merge(NewSpaceSaving[int](k + 1)); err != ErrIncompatible {
+		t.Fatalf("got %v merging differing k, want %v", err, ErrIncompatible)
+	}
+}
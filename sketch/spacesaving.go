@@ -0,0 +1,175 @@
+package sketch
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// Counter is an item tracked by SpaceSaving. Count overestimates the true count by at most Err.
+type Counter[T comparable] struct {
+	Item  T
+	Count uint64
+	Err   uint64
+}
+
+// SpaceSaving tracks the top-K heavy hitters of a stream in k counters. An unseen item evicts the
+// least counted item inheriting its count as error, so any item seen more than total/k times is
+// guaranteed to be tracked.
+//
+// Items are tracked by equality rather than hashing, so SpaceSaving needs no Hasher.
+type SpaceSaving[T comparable] struct {
+	k        int
+	counters spaceSavingHeap[T]
+	index    map[T]*spaceSavingCounter[T]
+}
+
+type spaceSavingCounter[T comparable] struct {
+	Counter[T]
+	i int // index in the heap
+}
+
+// spaceSavingHeap is a min heap of counters by count.
+type spaceSavingHeap[T comparable] []*spaceSavingCounter[T]
+
+func (h spaceSavingHeap[T]) Len() int           { return len(h) }
+func (h spaceSavingHeap[T]) Less(i, j int) bool { return h[i].Count < h[j].Count }
+func (h spaceSavingHeap[T]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].i, h[j].i = i, j
+}
+
+func (h *spaceSavingHeap[T]) Push(x any) {
+	c := x.(*spaceSavingCounter[T])
+	c.i = len(*h)
+	*h = append(*h, c)
+}
+
+func (h *spaceSavingHeap[T]) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return c
+}
+
+// NewSpaceSaving returns an empty SpaceSaving tracking k counters, at least one.
+func NewSpaceSaving[T comparable](k int) *SpaceSaving[T] {
+	k = max(k, 1)
+
+	return &SpaceSaving[T]{k: k, index: make(map[T]*spaceSavingCounter[T], k)}
+}
+
+// SpaceSavings returns a function creating a new SpaceSaving, for use as the acc function of
+// pipes.Window.
+func SpaceSavings[T comparable](k int) func() *SpaceSaving[T] {
+	return func() *SpaceSaving[T] { return NewSpaceSaving[T](k) }
+}
+
+func (s *SpaceSaving[T]) Add(t T) {
+	s.AddN(t, 1)
+}
+
+// AddN records t as seen n times.
+func (s *SpaceSaving[T]) AddN(t T, n uint64) {
+	s.add(t, n, 0)
+}
+
+func (s *SpaceSaving[T]) add(t T, n, err uint64) {
+	if c, ok := s.index[t]; ok {
+		c.Count += n
+		c.Err += err
+		heap.Fix(&s.counters, c.i)
+		return
+	}
+
+	if len(s.counters) < s.k {
+		c := &spaceSavingCounter[T]{Counter: Counter[T]{Item: t, Count: n, Err: err}}
+		heap.Push(&s.counters, c)
+		s.index[t] = c
+		return
+	}
+
+	// evict the least counted item, t inherits its count as error
+	c := s.counters[0]
+	delete(s.index, c.Item)
+	c.Item, c.Err, c.Count = t, c.Count+err, c.Count+n
+	s.index[t] = c
+	heap.Fix(&s.counters, 0)
+}
+
+// floor returns the most an untracked item can have been seen.
+func (s *SpaceSaving[T]) floor() uint64 {
+	if len(s.counters) < s.k {
+		return 0
+	}
+
+	return s.counters[0].Count
+}
+
+// Estimate returns the estimated count of t, along with the most it may overcount by. An untracked
+// item returns the least tracked count once all counters are in use.
+func (s *SpaceSaving[T]) Estimate(t T) (count, err uint64) {
+	if c, ok := s.index[t]; ok {
+		return c.Count, c.Err
+	}
+
+	f := s.floor()
+	return f, f
+}
+
+// Top returns up to n tracked items by descending count, all of them if n is less than one.
+func (s *SpaceSaving[T]) Top(n int) []Counter[T] {
+	top := make([]Counter[T], len(s.counters))
+	for i, c := range s.counters {
+		top[i] = c.Counter
+	}
+
+	sort.Slice(top, func(i, j int) bool { return top[i].Count > top[j].Count })
+	if n > 0 && n < len(top) {
+		top = top[:n]
+	}
+
+	return top
+}
+
+// Merge folds o into s as if s had seen every item o has, keeping the k most counted items. An
+// item tracked by only one sketch is credited the other's least tracked count as both count and
+// error. Both must track the same k.
+func (s *SpaceSaving[T]) Merge(o *SpaceSaving[T]) error {
+	if s.k != o.k {
+		return ErrIncompatible
+	}
+
+	sFloor, oFloor := s.floor(), o.floor()
+
+	merged := make(map[T]Counter[T], len(s.counters)+len(o.counters))
+	for _, c := range s.counters {
+		merged[c.Item] = Counter[T]{Item: c.Item, Count: c.Count + oFloor, Err: c.Err + oFloor}
+	}
+	for _, c := range o.counters {
+		if m, ok := merged[c.Item]; ok {
+			m.Count += c.Count - oFloor
+			m.Err += c.Err - oFloor
+			merged[c.Item] = m
+			continue
+		}
+		merged[c.Item] = Counter[T]{Item: c.Item, Count: c.Count + sFloor, Err: c.Err + sFloor}
+	}
+
+	all := make([]Counter[T], 0, len(merged))
+	for _, c := range merged {
+		all = append(all, c)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Count > all[j].Count })
+	if len(all) > s.k {
+		all = all[:s.k]
+	}
+
+	s.counters = s.counters[:0]
+	clear(s.index)
+	for _, c := range all {
+		s.add(c.Item, c.Count, c.Err)
+	}
+
+	return nil
+}
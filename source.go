@@ -1,5 +1,10 @@
 package pipes
 
+import (
+	"context"
+	"errors"
+)
+
 const RepeatForever = -1
 
 func Source[T any](repeat, size int, source func() T) ChanPull[T] {
@@ -13,11 +18,38 @@ func Source[T any](repeat, size int, source func() T) ChanPull[T] {
 func sourceWorker[T any](repeat int, source func() T, out chan<- T) {
 	defer close(out)
 
-	for i := 0; repeat == RepeatForever || repeat > 0; i++ {
+	for i := 0; repeat == RepeatForever || i < repeat; i++ {
 		out <- source()
 	}
 }
 
+// SourceWithContext is Source stopping and closing the returned channel once ctx is cancelled, such
+// as by TakeWithCancel once it has taken enough.
+func SourceWithContext[T any](ctx context.Context, repeat, size int, source func() T) ChanPull[T] {
+	out := make(chan T, size)
+
+	go sourceWithContextWorker(ctx, repeat, source, out)
+
+	return out
+}
+
+func sourceWithContextWorker[T any](ctx context.Context, repeat int, source func() T, out chan<- T) {
+	defer close(out)
+
+	for i := 0; repeat == RepeatForever || i < repeat; i++ {
+		// checked first as select would otherwise pick at random while out has room
+		if ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case out <- source():
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 func SourceWithError[T any](repeat, size int, source func() (T, error)) (ChanPull[T], ChanPull[error]) {
 	out, err := make(chan T, size), make(chan error, size)
 
@@ -29,9 +61,12 @@ func SourceWithError[T any](repeat, size int, source func() (T, error)) (ChanPul
 func sourceWithErrorWorker[T any](repeat int, source func() (T, error), out chan<- T, err chan<- error) {
 	defer func() { close(err); close(out) }()
 
-	for i := 0; repeat == RepeatForever || repeat > 0; i++ {
+	for i := 0; repeat == RepeatForever || i < repeat; i++ {
 		if v, er := source(); er != nil {
 			err <- er
+			if errors.Is(er, ErrStop) {
+				return
+			}
 		} else {
 			out <- v
 		}
@@ -49,11 +84,162 @@ func SourceWithErrorSink[T any](repeat, size int, source func() (T, error), sink
 func sourceWithErrorSinkWorker[T any](repeat int, source func() (T, error), sink func(error), out chan<- T) {
 	defer close(out)
 
-	for i := 0; repeat == RepeatForever || repeat > 0; i++ {
+	for i := 0; repeat == RepeatForever || i < repeat; i++ {
 		if v, err := source(); err != nil {
 			sink(err)
+			if errors.Is(err, ErrStop) {
+				return
+			}
 		} else {
 			out <- v
 		}
 	}
 }
+
+// FromSlice pushes each T in s onto the returned channel in order, closing it once done.
+func FromSlice[T any](size int, s []T) ChanPull[T] {
+	out := make(chan T, size)
+
+	go fromSliceWorker(s, out)
+
+	return out
+}
+
+func fromSliceWorker[T any](s []T, out chan<- T) {
+	defer close(out)
+
+	for _, t := range s {
+		out <- t
+	}
+}
+
+// FromMap pushes each key value pair in m onto the returned channel as a KV, closing it once done.
+// As with ranging over a map the order is not specified.
+func FromMap[K comparable, V any](size int, m map[K]V) ChanPull[KV[K, V]] {
+	out := make(chan KV[K, V], size)
+
+	go fromMapWorker(m, out)
+
+	return out
+}
+
+func fromMapWorker[K comparable, V any](m map[K]V, out chan<- KV[K, V]) {
+	defer close(out)
+
+	for k, v := range m {
+		out <- KV[K, V]{Key: k, Value: v}
+	}
+}
+
+// Range pushes start, start+step, start+2*step and so on onto the returned channel while the value
+// has not reached stop, closing it once done. A negative step counts down towards stop and a step
+// of 0 produces nothing.
+func Range[T Number](size int, start, stop, step T) ChanPull[T] {
+	out := make(chan T, size)
+
+	go rangeWorker(start, stop, step, out)
+
+	return out
+}
+
+func rangeWorker[T Number](start, stop, step T, out chan<- T) {
+	defer close(out)
+
+	// the next value is checked before continuing as fixed width types wrap around when stepping
+	// past their max or min rather than reaching stop
+	switch {
+	case step > 0:
+		for t := start; t < stop; {
+			out <- t
+
+			next := t + step
+			if next <= t || next >= stop {
+				return
+			}
+			t = next
+		}
+
+	case step < 0:
+		for t := start; t > stop; {
+			out <- t
+
+			next := t + step
+			if next >= t || next <= stop {
+				return
+			}
+			t = next
+		}
+	}
+}
+
+// Generate pushes seed followed by each value produced by repeatedly applying next to the previous
+// value onto the returned channel, repeat times in total or forever if repeat is RepeatForever.
+func Generate[T any](repeat, size int, seed T, next func(T) T) ChanPull[T] {
+	out := make(chan T, size)
+
+	go generateWorker(repeat, seed, next, out)
+
+	return out
+}
+
+func generateWorker[T any](repeat int, seed T, next func(T) T, out chan<- T) {
+	defer close(out)
+
+	for i := 0; repeat == RepeatForever || i < repeat; i++ {
+		if i > 0 {
+			seed = next(seed)
+		}
+		out <- seed
+	}
+}
+
+// GenerateWithContext is Generate stopping and closing the returned channel once ctx is cancelled.
+func GenerateWithContext[T any](ctx context.Context, repeat, size int, seed T, next func(T) T) ChanPull[T] {
+	out := make(chan T, size)
+
+	go generateWithContextWorker(ctx, repeat, seed, next, out)
+
+	return out
+}
+
+func generateWithContextWorker[T any](ctx context.Context, repeat int, seed T, next func(T) T, out chan<- T) {
+	defer close(out)
+
+	for i := 0; repeat == RepeatForever || i < repeat; i++ {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if i > 0 {
+			seed = next(seed)
+		}
+
+		select {
+		case out <- seed:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Repeat pushes t onto the returned channel repeat times, or forever if repeat is RepeatForever.
+func Repeat[T any](repeat, size int, t T) ChanPull[T] {
+	return Source(repeat, size, func() T { return t })
+}
+
+// RepeatWithContext is Repeat stopping and closing the returned channel once ctx is cancelled.
+func RepeatWithContext[T any](ctx context.Context, repeat, size int, t T) ChanPull[T] {
+	return SourceWithContext(ctx, repeat, size, func() T { return t })
+}
+
+// Empty returns a closed channel that produces nothing.
+func Empty[T any]() ChanPull[T] {
+	out := make(chan T)
+	close(out)
+	return out
+}
+
+// Never returns a channel that never produces a value and is never closed.
+func Never[T any]() ChanPull[T] {
+	return make(chan T)
+}
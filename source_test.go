@@ -0,0 +1,76 @@
+package pipes
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestRangeStopsAtTypeBounds(t *testing.T) {
+	if got := ToSlice(Range[uint8](0, 250, 255, 10)); !slices.Equal(got, []uint8{250}) {
+		t.Fatalf("uint8 got %v, want [250]", got)
+	}
+	if got := ToSlice(Range[int8](0, 120, math.MaxInt8, 5)); !slices.Equal(got, []int8{120, 125}) {
+		t.Fatalf("int8 got %v, want [120 125]", got)
+	}
+	if got := ToSlice(Range[int8](0, -120, math.MinInt8, -5)); !slices.Equal(got, []int8{-120, -125}) {
+		t.Fatalf("int8 down got %v, want [-120 -125]", got)
+	}
+	if got := ToSlice(Range[int8](0, -128, 127, 100)); !slices.Equal(got, []int8{-128, -28, 72}) {
+		t.Fatalf("int8 wide got %v, want [-128 -28 72]", got)
+	}
+}
+
+func TestRange(t *testing.T) {
+	if got := ToSlice(Range(0, 0, 10, 3)); !slices.Equal(got, []int{0, 3, 6, 9}) {
+		t.Fatalf("got %v, want [0 3 6 9]", got)
+	}
+	if got := ToSlice(Range(0, 3, 0, -1)); !slices.Equal(got, []int{3, 2, 1}) {
+		t.Fatalf("got %v, want [3 2 1]", got)
+	}
+	if got := ToSlice(Range(0, 0.0, 1, 0.25)); !slices.Equal(got, []float64{0, 0.25, 0.5, 0.75}) {
+		t.Fatalf("got %v, want [0 0.25 0.5 0.75]", got)
+	}
+	if got := ToSlice(Range(0, 0, 10, 0)); len(got) != 0 {
+		t.Fatalf("got %v for a zero step, want nothing", got)
+	}
+}
+
+func TestSourceRepeatsExactly(t *testing.T) {
+	calls := 0
+	got := ToSlice(Source(3, 0, func() int { calls++; return calls }))
+	if !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("got %v, want [1 2 3]", got)
+	}
+}
+
+func TestSourceWithErrorRepeatsExactly(t *testing.T) {
+	calls := 0
+	out, errs := SourceWithError(3, 3, func() (int, error) {
+		calls++
+		if calls == 2 {
+			return 0, errors.New("skipped")
+		}
+		return calls, nil
+	})
+
+	if got := ToSlice(out); !slices.Equal(got, []int{1, 3}) {
+		t.Fatalf("got %v, want [1 3]", got)
+	}
+	if n := len(ToSlice(errs)); n != 1 {
+		t.Fatalf("got %d errors, want 1", n)
+	}
+	if calls != 3 {
+		t.Fatalf("source called %d times, want 3", calls)
+	}
+}
+
+func TestSourceWithErrorSinkRepeatsExactly(t *testing.T) {
+	calls := 0
+	got := ToSlice(SourceWithErrorSink(3, 0, func() (int, error) { calls++; return calls, nil },
+		func(err error) { t.Error(err) }))
+	if !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("got %v, want [1 2 3]", got)
+	}
+}
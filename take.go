@@ -0,0 +1,186 @@
+package pipes
+
+import "context"
+
+// Take pushes the first n items read from in onto the returned channel, closing it once n items have
+// been pushed or in is closed. The remainder of in is then drained in the background so that
+// upstream stages are released rather than blocking on a channel nobody reads. Draining only ends
+// once in is closed, an upstream that never closes, such as a Source repeating forever, must also
+// be cancelled, see TakeWithCancel.
+func Take[T any](size, n int, in <-chan T) ChanPull[T] {
+	return TakeWithCancel(size, n, nil, in)
+}
+
+// TakeWithCancel is Take calling cancel once done so that the producers of in stop, such as a
+// SourceWithContext repeating forever:
+//
+//	ctx, cancel := context.WithCancel(ctx)
+//	first := pipes.TakeWithCancel(size, 10, cancel, pipes.SourceWithContext(ctx, pipes.RepeatForever, size, source))
+func TakeWithCancel[T any](size, n int, cancel context.CancelFunc, in <-chan T) ChanPull[T] {
+	out := make(chan T, size)
+
+	go takeWorker(n, cancel, in, out)
+
+	return out
+}
+
+// release cancels the producers of in, if cancel is not nil, and drains in in the background.
+func release[T any](cancel context.CancelFunc, in <-chan T) {
+	if cancel != nil {
+		cancel()
+	}
+	go ChanPull[T](in).Drain()
+}
+
+func takeWorker[T any](n int, cancel context.CancelFunc, in <-chan T, out chan<- T) {
+	defer close(out)
+	defer release(cancel, in)
+
+	for ; n > 0; n-- {
+		t, ok := <-in
+		if !ok {
+			return
+		}
+		out <- t
+	}
+}
+
+// Skip discards the first n items read from in, pushing every item after them onto the returned
+// channel.
+func Skip[T any](size, n int, in <-chan T) ChanPull[T] {
+	out := make(chan T, size)
+
+	go skipWorker(n, in, out)
+
+	return out
+}
+
+func skipWorker[T any](n int, in <-chan T, out chan<- T) {
+	defer close(out)
+
+	for t := range in {
+		if n > 0 {
+			n--
+			continue
+		}
+		out <- t
+	}
+}
+
+// TakeWhile pushes items read from in onto the returned channel for as long as keep returns true,
+// closing it at the first item for which keep returns false or once in is closed. The remainder of
+// in is then drained in the background so that upstream stages are released, see TakeWithCancel
+// for an upstream that never closes.
+func TakeWhile[T any](size int, keep func(T) bool, in <-chan T) ChanPull[T] {
+	return TakeWhileWithCancel(size, keep, nil, in)
+}
+
+// TakeWhileWithCancel is TakeWhile calling cancel once done so that the producers of in stop.
+func TakeWhileWithCancel[T any](size int, keep func(T) bool, cancel context.CancelFunc, in <-chan T) ChanPull[T] {
+	out := make(chan T, size)
+
+	go takeWhileWorker(keep, cancel, in, out)
+
+	return out
+}
+
+func takeWhileWorker[T any](keep func(T) bool, cancel context.CancelFunc, in <-chan T, out chan<- T) {
+	defer close(out)
+	defer release(cancel, in)
+
+	for t := range in {
+		if !keep(t) {
+			return
+		}
+		out <- t
+	}
+}
+
+// SkipWhile discards items read from in for as long as skip returns true, pushing the first item for
+// which skip returns false and every item after it onto the returned channel.
+func SkipWhile[T any](size int, skip func(T) bool, in <-chan T) ChanPull[T] {
+	out := make(chan T, size)
+
+	go skipWhileWorker(skip, in, out)
+
+	return out
+}
+
+func skipWhileWorker[T any](skip func(T) bool, in <-chan T, out chan<- T) {
+	defer close(out)
+
+	skipping := true
+	for t := range in {
+		if skipping && skip(t) {
+			continue
+		}
+		skipping = false
+		out <- t
+	}
+}
+
+// TakeUntil pushes items read from in onto the returned channel until signal is closed or receives
+// a value, such as from ctx.Done(), or in is closed. The remainder of in is then drained in the
+// background so that upstream stages are released, see TakeWithCancel for an upstream that never
+// closes.
+func TakeUntil[T any](size int, signal <-chan struct{}, in <-chan T) ChanPull[T] {
+	return TakeUntilWithCancel(size, signal, nil, in)
+}
+
+// TakeUntilWithCancel is TakeUntil calling cancel once done so that the producers of in stop.
+func TakeUntilWithCancel[T any](size int, signal <-chan struct{}, cancel context.CancelFunc, in <-chan T) ChanPull[T] {
+	out := make(chan T, size)
+
+	go takeUntilWorker(signal, cancel, in, out)
+
+	return out
+}
+
+func takeUntilWorker[T any](signal <-chan struct{}, cancel context.CancelFunc, in <-chan T, out chan<- T) {
+	defer close(out)
+	defer release(cancel, in)
+
+	for {
+		select {
+		case <-signal:
+			return
+
+		case t, ok := <-in:
+			if !ok {
+				return
+			}
+
+			select {
+			case out <- t:
+			case <-signal:
+				return
+			}
+		}
+	}
+}
+
+// First is a blocking operation that returns the first item read from in, or false if in is closed
+// without producing one. The remainder of in is drained in the background so that upstream stages
+// are released, see TakeWithCancel for an upstream that never closes.
+func First[T any](in <-chan T) (T, bool) {
+	return FirstWithCancel(nil, in)
+}
+
+// FirstWithCancel is First calling cancel once the first item is read so that the producers of in
+// stop.
+func FirstWithCancel[T any](cancel context.CancelFunc, in <-chan T) (T, bool) {
+	t, ok := <-in
+	release(cancel, in)
+
+	return t, ok
+}
+
+// Last is a blocking operation that returns the last item read from in once it is closed, or false
+// if in is closed without producing one.
+func Last[T any](in <-chan T) (last T, ok bool) {
+	for t := range in {
+		last, ok = t, true
+	}
+
+	return last, ok
+}
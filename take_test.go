@@ -0,0 +1,85 @@
+package pipes
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// watch forwards in, closing stopped once in is closed by its producer.
+func watch[T any](in <-chan T) (ChanPull[T], <-chan struct{}) {
+	out, stopped := make(chan T), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		defer close(out)
+		for t := range in {
+			out <- t
+		}
+	}()
+
+	return out, stopped
+}
+
+func waitStopped(t *testing.T, stopped <-chan struct{}) {
+	t.Helper()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("producer still running")
+	}
+}
+
+func TestTakeWithCancelStopsSource(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in, stopped := watch(SourceWithContext(ctx, RepeatForever, 0, func() int { return 1 }))
+
+	if got := ToSlice(TakeWithCancel(0, 3, cancel, in)); !slices.Equal(got, []int{1, 1, 1}) {
+		t.Fatalf("got %v, want [1 1 1]", got)
+	}
+	waitStopped(t, stopped)
+}
+
+func TestTakeWhileWithCancelStopsGenerate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in, stopped := watch(GenerateWithContext(ctx, RepeatForever, 0, 1, func(i int) int { return i * 2 }))
+
+	keep := func(i int) bool { return i < 10 }
+	if got := ToSlice(TakeWhileWithCancel(0, keep, cancel, in)); !slices.Equal(got, []int{1, 2, 4, 8}) {
+		t.Fatalf("got %v, want [1 2 4 8]", got)
+	}
+	waitStopped(t, stopped)
+}
+
+func TestTakeUntilWithCancelStopsRepeat(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in, stopped := watch(RepeatWithContext(ctx, RepeatForever, 0, "a"))
+
+	signal := make(chan struct{})
+	out := TakeUntilWithCancel(0, signal, cancel, in)
+	<-out
+	close(signal)
+
+	out.Drain()
+	waitStopped(t, stopped)
+}
+
+func TestFirstWithCancelStopsSource(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in, stopped := watch(SourceWithContext(ctx, RepeatForever, 5, func() int { return 7 }))
+
+	if got, ok := FirstWithCancel(cancel, in); !ok || got != 7 {
+		t.Fatalf("got %v %v, want 7 true", got, ok)
+	}
+	waitStopped(t, stopped)
+}
+
+func TestTakeWithoutCancelDrains(t *testing.T) {
+	in, stopped := watch(Range(0, 0, 100, 1))
+
+	if got := ToSlice(Take(0, 2, in)); !slices.Equal(got, []int{0, 1}) {
+		t.Fatalf("got %v, want [0 1]", got)
+	}
+	waitStopped(t, stopped)
+}
@@ -31,6 +31,9 @@ func tapWithErrorWorker[T any](tap func(T) error, in <-chan T, out chan<- T, err
 	for t := range in {
 		if er := tap(t); er != nil {
 			err <- er
+			if stopping(er, in) {
+				return
+			}
 		}
 		out <- t
 	}
@@ -50,6 +53,9 @@ func tapWithErrorSinkWorker[T any](mp func(T) error, sink func(error), in <-chan
 	for t := range in {
 		if er := mp(t); er != nil {
 			sink(er)
+			if stopping(er, in) {
+				return
+			}
 		}
 		out <- t
 	}
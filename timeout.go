@@ -0,0 +1,73 @@
+package pipes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TimeoutError is reported in place of the result of an item whose processing timed out.
+type TimeoutError[T any] struct {
+	Item    T
+	Timeout time.Duration
+}
+
+func (e *TimeoutError[T]) Error() string {
+	return fmt.Sprintf("pipes: timed out after %s", e.Timeout)
+}
+
+// Unwrap returns context.DeadlineExceeded so that errors.Is can detect timeouts regardless of T.
+func (e *TimeoutError[T]) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// TimeoutMap wraps mp so that each call is given a context with a deadline timeout from now. Should
+// mp not return by the deadline a *TimeoutError carrying the input item is returned instead and the
+// worker moves on, mp is left to observe the cancelled context and return in the background. A
+// result returned right at the deadline is kept rather than reported as a timeout. The returned
+// function can be passed to MapWithError or its sink and async counterparts.
+func TimeoutMap[T any, N any](timeout time.Duration, mp func(context.Context, T) (N, error)) func(T) (N, error) {
+	type result struct {
+		n   N
+		err error
+	}
+
+	return func(t T) (N, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		// buffered so that an abandoned call can still complete and exit
+		res := make(chan result, 1)
+		go func() {
+			n, err := mp(ctx, t)
+			res <- result{n, err}
+		}()
+
+		select {
+		case r := <-res:
+			return r.n, r.err
+		case <-ctx.Done():
+			// select picks at random when both are ready, a result already sent is kept unless mp
+			// gave up due to the deadline
+			select {
+			case r := <-res:
+				if !errors.Is(r.err, context.DeadlineExceeded) {
+					return r.n, r.err
+				}
+			default:
+			}
+
+			var n N
+			return n, &TimeoutError[T]{Item: t, Timeout: timeout}
+		}
+	}
+}
+
+func MapWithTimeout[T any, N any](size int, timeout time.Duration, mp func(context.Context, T) (N, error), in <-chan T) (ChanPull[N], ChanPull[error]) {
+	return MapWithError(size, TimeoutMap(timeout, mp), in)
+}
+
+func MapWithTimeoutErrorSink[T any, N any](size int, timeout time.Duration, mp func(context.Context, T) (N, error), sink func(error), in <-chan T) ChanPull[N] {
+	return MapWithErrorSink(size, TimeoutMap(timeout, mp), sink, in)
+}
@@ -0,0 +1,54 @@
+package pipes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeoutMap(t *testing.T) {
+	mp := TimeoutMap(10*time.Millisecond, func(ctx context.Context, d time.Duration) (time.Duration, error) {
+		select {
+		case <-time.After(d):
+			return d, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	})
+
+	if got, err := mp(0); err != nil || got != 0 {
+		t.Fatalf("got %v %v, want 0 nil", got, err)
+	}
+
+	_, err := mp(time.Hour)
+	var timeout *TimeoutError[time.Duration]
+	if !errors.As(err, &timeout) || timeout.Item != time.Hour {
+		t.Fatalf("got %v, want a TimeoutError carrying the item", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want it to match context.DeadlineExceeded", err)
+	}
+}
+
+// Whether the wrapper sees the result or the deadline first is up to the scheduler, so this cannot
+// assert that a result sent at the deadline is kept. It exists to be run under -race, checking that
+// both branches are free of races and that any result returned is the one mp produced.
+func TestTimeoutMapResultRacingDeadline(t *testing.T) {
+	// returns a result rather than an error once the deadline passes, racing the timeout
+	mp := TimeoutMap(time.Millisecond, func(ctx context.Context, i int) (int, error) {
+		<-ctx.Done()
+		return i, nil
+	})
+
+	for i := range 100 {
+		got, err := mp(i)
+		var timeout *TimeoutError[int]
+		if err != nil && !errors.As(err, &timeout) {
+			t.Fatalf("got %v, want nil or a TimeoutError", err)
+		}
+		if err == nil && got != i {
+			t.Fatalf("got %d, want %d", got, i)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package pipes
+
+import "time"
+
+// Debounce pushes an item read from in only once quiet has passed without another item arriving,
+// each new item restarting the wait. Only the latest item of a burst is pushed, and any item still
+// waiting when in is closed is pushed before closing the returned channel. A nil clock uses
+// SystemClock.
+func Debounce[T any](size int, quiet time.Duration, clock Clock, in <-chan T) ChanPull[T] {
+	out := make(chan T, size)
+
+	go debounceWorker(quiet, clockOrSystem(clock), in, out)
+
+	return out
+}
+
+func debounceWorker[T any](quiet time.Duration, clock Clock, in <-chan T, out chan<- T) {
+	defer close(out)
+
+	timer := clock.NewTimer(quiet)
+	timer.Stop()
+	defer timer.Stop()
+
+	var latest T
+	pending := false
+	for {
+		select {
+		case t, ok := <-in:
+			if !ok {
+				if pending {
+					out <- latest
+				}
+				return
+			}
+			latest, pending = t, true
+			timer.Reset(quiet)
+
+		case <-timer.C():
+			if pending {
+				out <- latest
+				pending = false
+			}
+		}
+	}
+}
+
+// ThrottleFirst pushes the first item read from in and then discards every item arriving within
+// interval of it, after which the next item is pushed and the cycle repeats. A nil clock uses
+// SystemClock.
+func ThrottleFirst[T any](size int, interval time.Duration, clock Clock, in <-chan T) ChanPull[T] {
+	out := make(chan T, size)
+
+	go throttleFirstWorker(interval, clockOrSystem(clock), in, out)
+
+	return out
+}
+
+func throttleFirstWorker[T any](interval time.Duration, clock Clock, in <-chan T, out chan<- T) {
+	defer close(out)
+
+	var next time.Time
+	for t := range in {
+		if now := clock.Now(); !now.Before(next) {
+			next = now.Add(interval)
+			out <- t
+		}
+	}
+}
+
+// Sample pushes the latest item read from in every interval, provided an item has arrived since the
+// previous push. Items arriving after the last interval when in is closed are discarded. A nil clock
+// uses SystemClock.
+func Sample[T any](size int, interval time.Duration, clock Clock, in <-chan T) ChanPull[T] {
+	out := make(chan T, size)
+
+	go sampleWorker(interval, clockOrSystem(clock), in, out)
+
+	return out
+}
+
+func sampleWorker[T any](interval time.Duration, clock Clock, in <-chan T, out chan<- T) {
+	defer close(out)
+
+	ticker := clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	var latest T
+	pending := false
+	for {
+		select {
+		case t, ok := <-in:
+			if !ok {
+				return
+			}
+			latest, pending = t, true
+
+		case <-ticker.C():
+			if pending {
+				out <- latest
+				pending = false
+			}
+		}
+	}
+}
+
+// Audit starts waiting interval when an item is read from in while not already waiting, pushing
+// the latest item read once the wait is over. Unlike Debounce later items do not restart the wait,
+// so a continuous burst still produces an item every interval. An item still waiting when in is
+// closed is discarded. A nil clock uses SystemClock.
+func Audit[T any](size int, interval time.Duration, clock Clock, in <-chan T) ChanPull[T] {
+	out := make(chan T, size)
+
+	go auditWorker(interval, clockOrSystem(clock), in, out)
+
+	return out
+}
+
+func auditWorker[T any](interval time.Duration, clock Clock, in <-chan T, out chan<- T) {
+	defer close(out)
+
+	timer := clock.NewTimer(interval)
+	timer.Stop()
+	defer timer.Stop()
+
+	var latest T
+	pending := false
+	for {
+		select {
+		case t, ok := <-in:
+			if !ok {
+				return
+			}
+			latest = t
+			if !pending {
+				pending = true
+				timer.Reset(interval)
+			}
+
+		case <-timer.C():
+			if pending {
+				out <- latest
+				pending = false
+			}
+		}
+	}
+}
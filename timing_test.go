@@ -0,0 +1,165 @@
+package pipes
+
+import (
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// waitArmed waits for a timer or ticker on c to be due at epoch plus d, so that advancing the clock
+// does not race the stage arming it.
+func waitArmed(t *testing.T, c *ManualClock, d time.Duration) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		for _, w := range c.waiters {
+			if w.at.Equal(epoch.Add(d)) {
+				c.mu.Unlock()
+				return
+			}
+		}
+		c.mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("no timer armed for %s", d)
+}
+
+// nowClock is a ManualClock reporting each call to Now on nows, letting tests wait for a stage to
+// read the time.
+type nowClock struct {
+	*ManualClock
+	nows chan time.Time
+}
+
+func (c nowClock) Now() time.Time {
+	now := c.ManualClock.Now()
+	c.nows <- now
+	return now
+}
+
+func expect[T comparable](t *testing.T, out <-chan T, want T) {
+	t.Helper()
+
+	select {
+	case got := <-out:
+		if got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("got nothing, want %v", want)
+	}
+}
+
+func expectClosed[T any](t *testing.T, out <-chan T) {
+	t.Helper()
+
+	if got, ok := <-out; ok {
+		t.Fatalf("got %v, want closed", got)
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	clock := NewManualClock(epoch)
+	in := make(chan int)
+	out := Debounce(10, 10*time.Second, clock, in)
+
+	in <- 1
+	waitArmed(t, clock, 10*time.Second)
+	clock.Advance(5 * time.Second)
+
+	// restarts the wait
+	in <- 2
+	waitArmed(t, clock, 15*time.Second)
+	clock.Advance(9 * time.Second)
+
+	in <- 3
+	waitArmed(t, clock, 24*time.Second)
+	clock.Advance(10 * time.Second)
+	expect(t, out, 3)
+
+	// pushed when in is closed rather than dropped
+	in <- 4
+	close(in)
+	expect(t, out, 4)
+	expectClosed(t, out)
+}
+
+func TestThrottleFirst(t *testing.T) {
+	clock := nowClock{NewManualClock(epoch), make(chan time.Time)}
+	in := make(chan int)
+	out := ThrottleFirst(10, 10*time.Second, clock, in)
+
+	send := func(i int) {
+		in <- i
+		<-clock.nows
+	}
+
+	send(1)
+	clock.Advance(5 * time.Second)
+	send(2)
+	clock.Advance(5 * time.Second)
+	send(3)
+	send(4)
+	clock.Advance(10 * time.Second)
+	send(5)
+	close(in)
+
+	expect(t, out, 1)
+	expect(t, out, 3)
+	expect(t, out, 5)
+	expectClosed(t, out)
+}
+
+func TestSample(t *testing.T) {
+	clock := NewManualClock(epoch)
+	in := make(chan int)
+	out := Sample(10, 10*time.Second, clock, in)
+
+	waitArmed(t, clock, 10*time.Second)
+	in <- 1
+	in <- 2
+	clock.Advance(10 * time.Second)
+	expect(t, out, 2)
+
+	// nothing arrived, nothing pushed
+	clock.Advance(10 * time.Second)
+
+	in <- 3
+	clock.Advance(10 * time.Second)
+	expect(t, out, 3)
+
+	// discarded when in is closed
+	in <- 4
+	close(in)
+	expectClosed(t, out)
+}
+
+func TestAudit(t *testing.T) {
+	clock := NewManualClock(epoch)
+	in := make(chan int)
+	out := Audit(10, 10*time.Second, clock, in)
+
+	in <- 1
+	waitArmed(t, clock, 10*time.Second)
+	clock.Advance(5 * time.Second)
+
+	// does not restart the wait
+	in <- 2
+	clock.Advance(5 * time.Second)
+	expect(t, out, 2)
+
+	in <- 3
+	waitArmed(t, clock, 20*time.Second)
+	clock.Advance(10 * time.Second)
+	expect(t, out, 3)
+
+	// discarded when in is closed
+	in <- 4
+	close(in)
+	expectClosed(t, out)
+}
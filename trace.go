@@ -0,0 +1,246 @@
+package pipes
+
+import "context"
+
+// Span is a single unit of traced work started by a Tracer.
+type Span interface {
+	// End completes the span recording err if the traced work failed.
+	End(err error)
+}
+
+// Tracer starts spans for traced stages. Implementations are expected to derive the parent span, if
+// any, from ctx and return a context carrying the new span so that it becomes the parent of the
+// span started by the next stage. This keeps the module free of third party dependencies while
+// allowing an adapter to bridge to OpenTelemetry or similar outside of it.
+type Tracer interface {
+	Start(ctx context.Context, stage string) (context.Context, Span)
+}
+
+// Traced is the envelope carrying an item and its trace context between traced stages.
+type Traced[T any] struct {
+	Ctx   context.Context
+	Value T
+}
+
+// Trace wraps each item read from in into a Traced envelope rooted at context.Background.
+func Trace[T any](size int, in <-chan T) ChanPull[Traced[T]] {
+	return TraceContext(size, context.Background(), in)
+}
+
+// TraceContext wraps each item read from in into a Traced envelope rooted at ctx.
+func TraceContext[T any](size int, ctx context.Context, in <-chan T) ChanPull[Traced[T]] {
+	return Map(size, func(t T) Traced[T] { return Traced[T]{Ctx: ctx, Value: t} }, in)
+}
+
+// Untrace unwraps the item from each Traced envelope read from in.
+func Untrace[T any](size int, in <-chan Traced[T]) ChanPull[T] {
+	return Map(size, func(t Traced[T]) T { return t.Value }, in)
+}
+
+// MapWithTracer behaves as Map over Traced items, its worker covering each call to mp with a span
+// for the given stage. The span's context is passed to mp and carried on to the result.
+func MapWithTracer[T any, N any](tracer Tracer, stage string, size int, mp func(context.Context, T) N, in <-chan Traced[T]) ChanPull[Traced[N]] {
+	out := make(chan Traced[N], size)
+
+	go mapWithTracerWorker(tracer, stage, mp, in, out)
+
+	return out
+}
+
+func mapWithTracerWorker[T any, N any](tracer Tracer, stage string, mp func(context.Context, T) N, in <-chan Traced[T], out chan<- Traced[N]) {
+	defer close(out)
+
+	for t := range in {
+		ctx, span := tracer.Start(t.Ctx, stage)
+		n := mp(ctx, t.Value)
+		span.End(nil)
+		out <- Traced[N]{Ctx: ctx, Value: n}
+	}
+}
+
+// MapWithErrorTracer behaves as MapWithError over Traced items, its worker covering each call to mp
+// with a span for the given stage ended with any error returned.
+func MapWithErrorTracer[T any, N any](tracer Tracer, stage string, size int, mp func(context.Context, T) (N, error), in <-chan Traced[T]) (ChanPull[Traced[N]], ChanPull[error]) {
+	out, err := make(chan Traced[N], size), make(chan error, size)
+
+	go mapWithErrorTracerWorker(tracer, stage, mp, in, out, err)
+
+	return out, err
+}
+
+func mapWithErrorTracerWorker[T any, N any](tracer Tracer, stage string, mp func(context.Context, T) (N, error), in <-chan Traced[T], out chan<- Traced[N], err chan<- error) {
+	defer func() { close(out); close(err) }()
+
+	for t := range in {
+		ctx, span := tracer.Start(t.Ctx, stage)
+		n, er := mp(ctx, t.Value)
+		span.End(er)
+		if er != nil {
+			err <- er
+			if stopping(er, in) {
+				return
+			}
+		} else {
+			out <- Traced[N]{Ctx: ctx, Value: n}
+		}
+	}
+}
+
+// MapWithErrorSinkTracer behaves as MapWithErrorSink over Traced items, its worker covering each
+// call to mp with a span for the given stage ended with any error returned.
+func MapWithErrorSinkTracer[T any, N any](tracer Tracer, stage string, size int, mp func(context.Context, T) (N, error), sink func(error), in <-chan Traced[T]) ChanPull[Traced[N]] {
+	out := make(chan Traced[N], size)
+
+	go mapWithErrorSinkTracerWorker(tracer, stage, mp, sink, in, out)
+
+	return out
+}
+
+func mapWithErrorSinkTracerWorker[T any, N any](tracer Tracer, stage string, mp func(context.Context, T) (N, error), sink func(error), in <-chan Traced[T], out chan<- Traced[N]) {
+	defer close(out)
+
+	for t := range in {
+		ctx, span := tracer.Start(t.Ctx, stage)
+		n, er := mp(ctx, t.Value)
+		span.End(er)
+		if er != nil {
+			sink(er)
+			if stopping(er, in) {
+				return
+			}
+		} else {
+			out <- Traced[N]{Ctx: ctx, Value: n}
+		}
+	}
+}
+
+// FilterWithTracer behaves as Filter over Traced items, its worker covering each call to filter
+// with a span for the given stage. Items filtered out end their trace here.
+func FilterWithTracer[T any](tracer Tracer, stage string, size int, filter func(context.Context, T) bool, in <-chan Traced[T]) ChanPull[Traced[T]] {
+	out := make(chan Traced[T], size)
+
+	go filterWithTracerWorker(tracer, stage, filter, in, out)
+
+	return out
+}
+
+func filterWithTracerWorker[T any](tracer Tracer, stage string, filter func(context.Context, T) bool, in <-chan Traced[T], out chan<- Traced[T]) {
+	defer close(out)
+
+	for t := range in {
+		ctx, span := tracer.Start(t.Ctx, stage)
+		keep := filter(ctx, t.Value)
+		span.End(nil)
+		if keep {
+			out <- Traced[T]{Ctx: ctx, Value: t.Value}
+		}
+	}
+}
+
+// FilterWithErrorTracer behaves as FilterWithError over Traced items, its worker covering each call
+// to filter with a span for the given stage ended with any error returned.
+func FilterWithErrorTracer[T any](tracer Tracer, stage string, size int, filter func(context.Context, T) (bool, error), in <-chan Traced[T]) (ChanPull[Traced[T]], ChanPull[error]) {
+	out, err := make(chan Traced[T], size), make(chan error, size)
+
+	go filterWithErrorTracerWorker(tracer, stage, filter, in, out, err)
+
+	return out, err
+}
+
+func filterWithErrorTracerWorker[T any](tracer Tracer, stage string, filter func(context.Context, T) (bool, error), in <-chan Traced[T], out chan<- Traced[T], err chan<- error) {
+	defer func() { close(out); close(err) }()
+
+	for t := range in {
+		ctx, span := tracer.Start(t.Ctx, stage)
+		keep, er := filter(ctx, t.Value)
+		span.End(er)
+		if er != nil {
+			err <- er
+			if stopping(er, in) {
+				return
+			}
+		} else if keep {
+			out <- Traced[T]{Ctx: ctx, Value: t.Value}
+		}
+	}
+}
+
+// FilterWithErrorSinkTracer behaves as FilterWithErrorSink over Traced items, its worker covering
+// each call to filter with a span for the given stage ended with any error returned.
+func FilterWithErrorSinkTracer[T any](tracer Tracer, stage string, size int, filter func(context.Context, T) (bool, error), sink func(error), in <-chan Traced[T]) ChanPull[Traced[T]] {
+	out := make(chan Traced[T], size)
+
+	go filterWithErrorSinkTracerWorker(tracer, stage, filter, sink, in, out)
+
+	return out
+}
+
+func filterWithErrorSinkTracerWorker[T any](tracer Tracer, stage string, filter func(context.Context, T) (bool, error), sink func(error), in <-chan Traced[T], out chan<- Traced[T]) {
+	defer close(out)
+
+	for t := range in {
+		ctx, span := tracer.Start(t.Ctx, stage)
+		keep, er := filter(ctx, t.Value)
+		span.End(er)
+		if er != nil {
+			sink(er)
+			if stopping(er, in) {
+				return
+			}
+		} else if keep {
+			out <- Traced[T]{Ctx: ctx, Value: t.Value}
+		}
+	}
+}
+
+// SinkWithTracer behaves as Sink over Traced items, covering each call to sink with a span for the
+// given stage.
+func SinkWithTracer[T any](tracer Tracer, stage string, sink func(context.Context, T), in <-chan Traced[T]) {
+	for t := range in {
+		ctx, span := tracer.Start(t.Ctx, stage)
+		sink(ctx, t.Value)
+		span.End(nil)
+	}
+}
+
+// SinkWithErrorTracer behaves as SinkWithError over Traced items, its worker covering each call to
+// sink with a span for the given stage ended with any error returned.
+func SinkWithErrorTracer[T any](tracer Tracer, stage string, size int, sink func(context.Context, T) error, in <-chan Traced[T]) ChanPull[error] {
+	err := make(chan error, size)
+
+	go sinkWithErrorTracerWorker(tracer, stage, sink, in, err)
+
+	return err
+}
+
+func sinkWithErrorTracerWorker[T any](tracer Tracer, stage string, sink func(context.Context, T) error, in <-chan Traced[T], err chan<- error) {
+	defer close(err)
+
+	for t := range in {
+		ctx, span := tracer.Start(t.Ctx, stage)
+		er := sink(ctx, t.Value)
+		span.End(er)
+		if er != nil {
+			err <- er
+			if stopping(er, in) {
+				return
+			}
+		}
+	}
+}
+
+// SinkWithErrorSinkTracer behaves as SinkWithErrorSink over Traced items, covering each call to
+// sink with a span for the given stage ended with any error returned.
+func SinkWithErrorSinkTracer[T any](tracer Tracer, stage string, sink func(context.Context, T) error, errSink func(error), in <-chan Traced[T]) {
+	for t := range in {
+		ctx, span := tracer.Start(t.Ctx, stage)
+		err := sink(ctx, t.Value)
+		span.End(err)
+		if err != nil {
+			errSink(err)
+			if stopping(err, in) {
+				return
+			}
+		}
+	}
+}
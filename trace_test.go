@@ -0,0 +1,163 @@
+package pipes
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+type spanKey struct{}
+
+// recordedSpan is a span started by recorder, ended holds whether End was called and err what with.
+type recordedSpan struct {
+	id     int
+	parent int
+	stage  string
+	ended  bool
+	err    error
+	rec    *recorder
+}
+
+func (s *recordedSpan) End(err error) {
+	s.rec.mu.Lock()
+	defer s.rec.mu.Unlock()
+
+	s.ended, s.err = true, err
+}
+
+// recorder is a Tracer recording every span started, parented by the span carried by ctx.
+type recorder struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (r *recorder) Start(ctx context.Context, stage string) (context.Context, Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := &recordedSpan{id: len(r.spans) + 1, stage: stage, rec: r}
+	if parent, ok := ctx.Value(spanKey{}).(*recordedSpan); ok {
+		s.parent = parent.id
+	}
+	r.spans = append(r.spans, s)
+
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// stages returns the stages of the spans in the trace ending with the span carried by ctx, root
+// first.
+func (r *recorder) trace(ctx context.Context) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var stages []string
+	for s, _ := ctx.Value(spanKey{}).(*recordedSpan); s != nil; {
+		stages = append([]string{s.stage}, stages...)
+		if s.parent == 0 {
+			break
+		}
+		s = r.spans[s.parent-1]
+	}
+
+	return stages
+}
+
+func TestTraceUntrace(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "root")
+
+	traced := ToSlice(TraceContext(0, ctx, FromSlice(0, []int{1, 2})))
+	for _, tr := range traced {
+		if tr.Ctx.Value(key{}) != "root" {
+			t.Fatalf("got ctx %v, want rooted at ctx", tr.Ctx)
+		}
+	}
+
+	if got := ToSlice(Untrace(0, FromSlice(0, traced))); !slices.Equal(got, []int{1, 2}) {
+		t.Fatalf("got %v, want [1 2]", got)
+	}
+}
+
+func TestTracerSpansAcrossStages(t *testing.T) {
+	r := &recorder{}
+
+	double := MapWithTracer(r, "double", 0, func(_ context.Context, i int) int { return i * 2 }, Trace(0, FromSlice(0, []int{1, 2, 3})))
+	small := FilterWithTracer(r, "small", 0, func(_ context.Context, i int) bool { return i < 6 }, double)
+
+	var traces [][]string
+	SinkWithTracer(r, "sink", func(ctx context.Context, _ int) { traces = append(traces, r.trace(ctx)) }, small)
+
+	want := []string{"double", "small", "sink"}
+	if len(traces) != 2 || !slices.Equal(traces[0], want) || !slices.Equal(traces[1], want) {
+		t.Fatalf("got traces %v, want two of %v", traces, want)
+	}
+
+	// 3 mapped, 3 filtered with one dropped, 2 sunk
+	if len(r.spans) != 8 {
+		t.Fatalf("got %d spans, want 8", len(r.spans))
+	}
+	for _, s := range r.spans {
+		if !s.ended || s.err != nil {
+			t.Fatalf("span %d of %s ended %v with %v, want ended without error", s.id, s.stage, s.ended, s.err)
+		}
+	}
+}
+
+func TestTracerEndsSpansWithErrors(t *testing.T) {
+	r := &recorder{}
+	errOdd := errors.New("odd")
+
+	even := func(_ context.Context, i int) (int, error) {
+		if i%2 != 0 {
+			return 0, errOdd
+		}
+		return i, nil
+	}
+
+	var errs []error
+	mapped := MapWithErrorSinkTracer(r, "map", 0, even, func(err error) { errs = append(errs, err) }, Trace(0, FromSlice(0, []int{1, 2, 3, 4})))
+	sinkErrs := SinkWithErrorTracer(r, "sink", 0, func(_ context.Context, i int) error {
+		if i == 4 {
+			return errOdd
+		}
+		return nil
+	}, mapped)
+
+	if n := sinkErrs.Count(); n != 1 {
+		t.Fatalf("got %d sink errors, want 1", n)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("got %d map errors, want 2", len(errs))
+	}
+
+	failed := map[string]int{}
+	for _, s := range r.spans {
+		if !s.ended {
+			t.Fatalf("span %d of %s not ended", s.id, s.stage)
+		}
+		if s.err != nil {
+			failed[s.stage]++
+		}
+	}
+	if failed["map"] != 2 || failed["sink"] != 1 {
+		t.Fatalf("got failed spans %v, want 2 map and 1 sink", failed)
+	}
+}
+
+func TestTracerStopsOnErrStop(t *testing.T) {
+	r := &recorder{}
+
+	out, errs := MapWithErrorTracer(r, "map", 0, func(_ context.Context, i int) (int, error) {
+		if i == 2 {
+			return 0, ErrStop
+		}
+		return i, nil
+	}, Trace(0, FromSlice(0, []int{1, 2, 3, 4})))
+
+	go errs.Drain()
+	if got := ToSlice(Untrace(0, out)); !slices.Equal(got, []int{1}) {
+		t.Fatalf("got %v, want [1]", got)
+	}
+}